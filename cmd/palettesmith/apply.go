@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"palettesmith/internal/apply"
+	"palettesmith/internal/config"
+	"palettesmith/internal/plugin"
+	"strings"
+)
+
+// runApply writes the current theme to the given targets (default: all)
+func runApply(args []string, cfg config.Config) int {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	force := fs.Bool("force", false, "overwrite files that were edited outside palettesmith")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	st, err := plugin.Discover()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load plugins: %v\n", err)
+		return 1
+	}
+	plugs, err := selectPlugins(st, fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	th, err := loadTheme(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load theme: %v\n", err)
+		return 1
+	}
+	targets := make([]apply.Target, 0, len(plugs))
+	for _, plug := range plugs {
+		targets = append(targets, apply.Target{Plugin: plug, Values: apply.Values(plug, th)})
+	}
+
+	// Journal the theme the values came from, not just the active link
+	opts := apply.Options{Theme: th.Theme}
+	if journal, err := config.HistoryPath(); err == nil {
+		opts.Journal = journal
+	}
+	if *force {
+		opts.OnConflict = apply.ConflictOverwrite
+	}
+	l, err := config.Lock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Apply failed: %v\n", err)
+		return 1
+	}
+	defer l.Release()
+
+	batch, err := apply.RunAll(cfg, targets, opts)
+	var ce *apply.ConflictError
+	if errors.As(err, &ce) {
+		fmt.Fprintln(os.Stderr, "These files were edited since palettesmith last wrote them:")
+		for _, c := range ce.Conflicts {
+			fmt.Fprintf(os.Stderr, "  %s (%s)\n", c.Path, c.PluginID)
+		}
+		fmt.Fprintln(os.Stderr, "Nothing was written. Re-run with --force to overwrite them.")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Apply failed: %v\n", err)
+		return 1
+	}
+
+	code := 0
+	for _, v := range batch.VerifyFailures() {
+		code = 1
+		fmt.Fprintf(os.Stderr, "Not applying %s: %v\n", v.PluginID, v.Err)
+		fmt.Fprintf(os.Stderr, "  command: %s\n", strings.Join(v.Command, " "))
+		if out := strings.TrimSpace(v.Stdout + v.Stderr); out != "" {
+			fmt.Fprintf(os.Stderr, "  output:\n%s\n", indent(out, "    "))
+		}
+	}
+	if batch.Backup == "" {
+		if code == 0 {
+			fmt.Printf("%s already up to date\n", batch.Targets())
+		} else {
+			fmt.Println("Nothing applied")
+		}
+		return code
+	}
+
+	var reloads []apply.ReloadResult
+	for _, r := range batch.Results {
+		for _, p := range r.Written {
+			fmt.Printf("Wrote %s\n", p)
+		}
+		for _, p := range r.Unchanged {
+			fmt.Printf("Unchanged %s\n", p)
+		}
+		for _, w := range r.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		if r.Reload != nil {
+			reloads = append(reloads, *r.Reload)
+		}
+	}
+	fmt.Printf("Applied %s (backup %s)\n", batch.Targets(), batch.Backup)
+	return max(code, reportReloads(reloads))
+}
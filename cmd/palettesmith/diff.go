@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"palettesmith/internal/apply"
+	"palettesmith/internal/config"
+	"palettesmith/internal/diff"
+	"palettesmith/internal/plugin"
+)
+
+// runDiff prints the pending changes of the given targets as unified diffs
+func runDiff(args []string, cfg config.Config) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	exitCode := fs.Bool("exit-code", false, "exit with status 1 when there are pending changes")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	st, err := plugin.Discover()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load plugins: %v\n", err)
+		return 1
+	}
+	plugs, err := selectPlugins(st, fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	th, err := loadTheme(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load theme: %v\n", err)
+		return 1
+	}
+	changed := false
+	for _, plug := range plugs {
+		diffs, err := apply.Preview(plug, apply.Values(plug, th))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot preview %s: %v\n", plug.Manifest.ID, err)
+			return 1
+		}
+		for _, d := range diffs {
+			if d.Changed() {
+				changed = true
+				fmt.Print(diff.Colorize(d.Unified))
+			}
+		}
+	}
+
+	if changed && *exitCode {
+		return 1
+	}
+	return 0
+}
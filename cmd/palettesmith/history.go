@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"palettesmith/internal/config"
+	"palettesmith/internal/history"
+	"strings"
+	"time"
+)
+
+// runHistory prints the apply journal, newest first
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	target := fs.String("target", "", "only show applies that wrote this target")
+	since := fs.Duration("since", 0, "only show applies within this long ago, e.g. 24h")
+	limit := fs.Int("n", 20, "show at most this many applies (0 for all)")
+	asJSON := fs.Bool("json", false, "print the matching entries as JSON lines")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	path, err := config.HistoryPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to locate history: %v\n", err)
+		return 1
+	}
+	entries, err := history.Read(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read history: %v\n", err)
+		return 1
+	}
+
+	shown := 0
+	enc := json.NewEncoder(os.Stdout)
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if *target != "" && !e.Touches(strings.ToLower(*target)) {
+			continue
+		}
+		if *since > 0 && time.Since(e.Time) > *since {
+			break
+		}
+		if *limit > 0 && shown == *limit {
+			break
+		}
+		shown++
+		if *asJSON {
+			_ = enc.Encode(e)
+			continue
+		}
+		printEntry(e)
+	}
+	if shown == 0 && !*asJSON {
+		fmt.Println("No applies recorded")
+	}
+	return 0
+}
+
+func printEntry(e history.Entry) {
+	fmt.Printf("%s  theme %s  %s  (backup %s)\n",
+		e.Time.Local().Format("2006-01-02 15:04:05"), nonEmpty(e.Theme, "—"), strings.Join(e.Targets, ", "), nonEmpty(e.Backup, "—"))
+	for _, f := range e.Files {
+		fmt.Printf("  wrote %s  %.12s\n", f.Path, f.SHA256)
+	}
+	for _, r := range e.Reloads {
+		if r.Failed() {
+			fmt.Printf("  reload %s failed: %s\n", r.Target, r.Error)
+			continue
+		}
+		fmt.Printf("  reload %s ok (%dms)\n", r.Target, r.DurationMS)
+	}
+}
+
+func nonEmpty(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
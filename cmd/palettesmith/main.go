@@ -11,9 +11,35 @@ import (
 
 func main() {
 	configManager := initializeConfig()
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:], configManager))
+	}
 	runApplication(configManager)
 }
 
+// runCommand dispatches a CLI subcommand and returns the process exit code
+func runCommand(name string, args []string, configManager *config.Manager) int {
+	switch name {
+	case "apply":
+		return runApply(args, configManager.GetConfig())
+	case "rollback":
+		return runRollback(args, configManager.GetConfig())
+	case "diff":
+		return runDiff(args, configManager.GetConfig())
+	case "plan":
+		return runPlan(args, configManager.GetConfig())
+	case "plugin":
+		return runPlugin(args)
+	case "history":
+		return runHistory(args)
+	case "theme":
+		return runTheme(args, configManager.GetConfig())
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command '%s'\n", name)
+		return 2
+	}
+}
+
 // initializeConfig creates and returns a new config manager, exiting on failure
 func initializeConfig() *config.Manager {
 	configManager, err := config.NewManager()
@@ -29,7 +55,7 @@ func createInitialModel(configManager *config.Manager) tea.Model {
 	if configManager.IsFirstRun() {
 		return tui.NewSetupModel()
 	}
-	return tui.New()
+	return tui.New(configManager.GetConfig())
 }
 
 // handleSetupCompletion processes setup completion and configures the chosen preset
@@ -63,7 +89,7 @@ func runApplication(configManager *config.Manager) {
 		if setupModel, ok := finalModel.(tui.SetupModel); ok && setupModel.IsConfirmed() {
 			handleSetupCompletion(setupModel, configManager)
 			// Start the main application
-			p = tea.NewProgram(tui.New(), tea.WithAltScreen())
+			p = tea.NewProgram(tui.New(configManager.GetConfig()), tea.WithAltScreen())
 			continue
 		}
 
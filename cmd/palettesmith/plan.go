@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"palettesmith/internal/apply"
+	"palettesmith/internal/config"
+	"palettesmith/internal/plugin"
+	"strings"
+)
+
+// runPlan prints what applying the given targets would do without writing
+func runPlan(args []string, cfg config.Config) int {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the plan as JSON")
+	exitCode := fs.Bool("exit-code", false, "exit with status 1 when the apply would change files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	st, err := plugin.Discover()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load plugins: %v\n", err)
+		return 1
+	}
+	plugs, err := selectPlugins(st, fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	th, err := loadTheme(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load theme: %v\n", err)
+		return 1
+	}
+	plan, err := apply.BuildPlan(th.Theme, plugs, th)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to plan: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plan); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode plan: %v\n", err)
+			return 1
+		}
+	} else {
+		printPlan(plan)
+	}
+
+	switch {
+	case plan.Failed():
+		return 1
+	case plan.Changed() && *exitCode:
+		return 1
+	}
+	return 0
+}
+
+func printPlan(plan apply.Plan) {
+	fmt.Printf("Theme: %s\n", nonEmpty(plan.Theme, "—"))
+	for _, t := range plan.Targets {
+		fmt.Printf("\n%s (%s)\n", t.ID, t.Mode)
+		if t.Error != "" {
+			fmt.Printf("  error: %s\n", t.Error)
+		}
+		for _, v := range t.Values {
+			fmt.Printf("  %s = %s  [%s]\n", v.Key, v.Value, v.Source)
+		}
+		for _, f := range t.Files {
+			fmt.Printf("  %-9s %s\n", f.Action, f.Path)
+		}
+		if len(t.Verify) > 0 {
+			fmt.Printf("  verify: %s\n", strings.Join(t.Verify, " "))
+		}
+		if len(t.Reload) > 0 {
+			fmt.Printf("  reload: %s\n", strings.Join(t.Reload, " "))
+		}
+		for _, w := range t.Warnings {
+			fmt.Printf("  warning: %s\n", w)
+		}
+	}
+}
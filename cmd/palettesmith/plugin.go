@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"palettesmith/internal/plugin"
+	"path/filepath"
+)
+
+// runPlugin dispatches the plugin authoring subcommands
+func runPlugin(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: palettesmith plugin validate [dir...] | schema manifest|spec | eject <id>")
+		return 2
+	}
+	switch args[0] {
+	case "validate":
+		return runPluginValidate(args[1:])
+	case "schema":
+		return runPluginSchema(args[1:])
+	case "eject":
+		return runPluginEject(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown plugin command '%s'\n", args[0])
+		return 2
+	}
+}
+
+// runPluginValidate checks plugin directories and prints file:line:column
+// diagnostics; with no arguments every installed plugin is checked
+func runPluginValidate(args []string) int {
+	fs := flag.NewFlagSet("plugin validate", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "treat warnings as errors")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		for _, root := range plugin.Roots() {
+			found, err := filepath.Glob(filepath.Join(root, "*", "plugin.json"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to find plugins: %v\n", err)
+				return 1
+			}
+			for _, mf := range found {
+				dirs = append(dirs, filepath.Dir(mf))
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		fmt.Fprintln(os.Stderr, "No plugins to validate")
+		return 1
+	}
+
+	failed := 0
+	for _, dir := range dirs {
+		diags := plugin.ValidateDir(dir)
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+		if plugin.HasErrors(diags) || (*strict && len(diags) > 0) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d plugin(s) failed validation\n", failed, len(dirs))
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "%d plugin(s) valid\n", len(dirs))
+	return 0
+}
+
+// runPluginSchema prints one of the published JSON Schemas
+func runPluginSchema(args []string) int {
+	name := map[string]string{"manifest": plugin.ManifestSchema, "spec": plugin.SpecSchema}
+	if len(args) != 1 || name[args[0]] == "" {
+		fmt.Fprintln(os.Stderr, "Usage: palettesmith plugin schema manifest|spec")
+		return 2
+	}
+	data, err := plugin.Schemas.ReadFile(name[args[0]])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	os.Stdout.Write(data)
+	return 0
+}
+
+// runPluginEject copies a built-in plugin into the user plugin root, where it
+// overrides the built-in and can be edited
+func runPluginEject(args []string) int {
+	fs := flag.NewFlagSet("plugin eject", flag.ContinueOnError)
+	dir := fs.String("dir", plugin.UserRoot(), "plugin root to copy into")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: palettesmith plugin eject [--dir root] <id>")
+		return 2
+	}
+	if *dir == "" {
+		fmt.Fprintln(os.Stderr, "Cannot determine the user plugin root; pass --dir")
+		return 1
+	}
+
+	dest, err := plugin.Eject(fs.Arg(0), *dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Eject failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Ejected %s to %s; it now overrides the built-in plugin\n", fs.Arg(0), dest)
+	return 0
+}
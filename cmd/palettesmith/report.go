@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"palettesmith/internal/apply"
+	"strings"
+	"time"
+)
+
+// reportReloads prints reload results and returns a non-zero code if any failed
+func reportReloads(results []apply.ReloadResult) int {
+	code := 0
+	for _, r := range results {
+		if !r.Failed() {
+			fmt.Printf("Reloaded %s (%s)\n", r.PluginID, r.Duration.Round(time.Millisecond))
+			continue
+		}
+		code = 1
+		fmt.Fprintf(os.Stderr, "Reload %s failed: %v\n", r.PluginID, r.Err)
+		fmt.Fprintf(os.Stderr, "  command: %s\n", strings.Join(r.Command, " "))
+		if r.ExitCode != 0 {
+			fmt.Fprintf(os.Stderr, "  exit code: %d\n", r.ExitCode)
+		}
+		if out := strings.TrimSpace(r.Stdout); out != "" {
+			fmt.Fprintf(os.Stderr, "  stdout:\n%s\n", indent(out, "    "))
+		}
+		if out := strings.TrimSpace(r.Stderr); out != "" {
+			fmt.Fprintf(os.Stderr, "  stderr:\n%s\n", indent(out, "    "))
+		}
+	}
+	return code
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
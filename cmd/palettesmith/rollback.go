@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"palettesmith/internal/apply"
+	"palettesmith/internal/config"
+	"palettesmith/internal/plugin"
+)
+
+// runRollback restores the live files saved by a previous apply
+func runRollback(args []string, cfg config.Config) int {
+	fs := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	to := fs.String("to", "", "backup generation to restore (default: the latest)")
+	list := fs.Bool("list", false, "list available backup generations")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *list {
+		gens, err := apply.Generations(cfg.StagingDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list backups: %v\n", err)
+			return 1
+		}
+		for _, g := range gens {
+			fmt.Printf("%s  %s  %d file(s)  %v\n", g.ID, g.Created.Local().Format("2006-01-02 15:04:05"), len(g.Files), g.Plugins)
+		}
+		return 0
+	}
+
+	l, err := config.Lock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Rollback failed: %v\n", err)
+		return 1
+	}
+	defer l.Release()
+
+	gen, err := apply.Rollback(cfg.StagingDir, *to)
+	if errors.Is(err, apply.ErrNoBackups) {
+		fmt.Fprintln(os.Stderr, "Nothing to roll back")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Rollback failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Restored backup %s (%d file(s))\n", gen.ID, len(gen.Files))
+
+	st, err := plugin.Discover()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load plugins for reload: %v\n", err)
+		return 1
+	}
+	return reportReloads(apply.ReloadPlugins(context.Background(), st, gen.Plugins))
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"palettesmith/internal/plugin"
+)
+
+// selectPlugins resolves target ids against the store; no ids means every plugin
+func selectPlugins(st *plugin.Store, ids []string) ([]plugin.Plugin, error) {
+	if len(ids) == 0 {
+		return st.List(), nil
+	}
+	plugs := make([]plugin.Plugin, 0, len(ids))
+	for _, id := range ids {
+		p, ok := st.Get(id)
+		if !ok {
+			return nil, fmt.Errorf("unknown target '%s'", id)
+		}
+		plugs = append(plugs, p)
+	}
+	return plugs, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"palettesmith/internal/config"
+	"palettesmith/internal/theme"
+)
+
+// loadTheme loads the active theme's palette and the saved overrides, the
+// same values the TUI applies
+func loadTheme(cfg config.Config) (*theme.Store, error) {
+	overrides, err := config.OverridesPath()
+	if err != nil {
+		return nil, err
+	}
+	return theme.Load(cfg.CurrentThemeLink, overrides)
+}
+
+// runTheme lists the installed themes or switches the active one
+func runTheme(args []string, cfg config.Config) int {
+	if len(args) == 0 || args[0] == "list" {
+		names, err := theme.Available(cfg.TargetThemeDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list themes: %v\n", err)
+			return 1
+		}
+		current, _ := theme.Current(cfg.CurrentThemeLink)
+		for _, n := range names {
+			marker := "  "
+			if n == current {
+				marker = "* "
+			}
+			fmt.Println(marker + n)
+		}
+		return 0
+	}
+
+	if args[0] != "use" || len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: palettesmith theme [list | use <name>]")
+		return 2
+	}
+	if err := theme.Activate(cfg.TargetThemeDir, cfg.CurrentThemeLink, args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to activate theme: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Activated theme %s\n", args[1])
+	return 0
+}
@@ -0,0 +1,10 @@
+// Package palettesmith holds the assets compiled into the binary
+package palettesmith
+
+import "embed"
+
+// BuiltinPlugins is the first-party plugins/ tree, discovered after every
+// on-disk plugin root so installed plugins can override it
+//
+//go:embed plugins
+var BuiltinPlugins embed.FS
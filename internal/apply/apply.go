@@ -0,0 +1,329 @@
+// Package apply renders plugin outputs into the staging directory and
+// promotes them to the live configuration paths.
+package apply
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"palettesmith/internal/config"
+	"palettesmith/internal/history"
+	"palettesmith/internal/paths"
+	"palettesmith/internal/plugin"
+	"palettesmith/internal/theme"
+	"path/filepath"
+	"strings"
+)
+
+// Output is a single rendered file destined for a live path
+type Output struct {
+	PluginID string
+	Path     string // absolute live destination
+	Content  []byte
+	Warnings []string
+	Region   Region // part of Path palettesmith manages; zero for the whole file
+}
+
+// Result describes what an apply run wrote
+type Result struct {
+	PluginID  string
+	Written   []string
+	Skipped   []string      // edited outside palettesmith and kept as they are
+	Unchanged []string      // already up to date, so not rewritten
+	Backup    string        // backup generation holding the previous live files
+	Verify    *VerifyResult // nil when the plugin has no verify command
+	Reload    *ReloadResult // nil when the plugin has no reload command
+	Warnings  []string
+}
+
+// Values resolves every spec field of a plugin against the theme store
+func Values(plug plugin.Plugin, th *theme.Store) map[string]string {
+	vals := make(map[string]string, len(plug.Spec.Fields))
+	for _, f := range plug.Spec.Fields {
+		if th != nil {
+			vals[f.Key] = th.Resolve(plug.Manifest.ID, f.Key, f.Default)
+		} else {
+			vals[f.Key] = f.Default
+		}
+	}
+	return vals
+}
+
+// Target is a plugin to apply together with its resolved values
+type Target struct {
+	Plugin plugin.Plugin
+	Values map[string]string
+}
+
+// Batch describes a transactional apply of several targets
+type Batch struct {
+	Results []Result // one per target, in order
+	Backup  string   // backup generation shared by every target
+}
+
+// Targets lists the applied plugin ids, comma separated
+func (b Batch) Targets() string {
+	ids := make([]string, 0, len(b.Results))
+	for _, r := range b.Results {
+		ids = append(ids, r.PluginID)
+	}
+	return strings.Join(ids, ", ")
+}
+
+// ReloadFailures returns the reload results that failed
+func (b Batch) ReloadFailures() []ReloadResult {
+	var failed []ReloadResult
+	for _, r := range b.Results {
+		if r.Reload != nil && r.Reload.Failed() {
+			failed = append(failed, *r.Reload)
+		}
+	}
+	return failed
+}
+
+// VerifyFailures returns the verify results that rejected a target
+func (b Batch) VerifyFailures() []VerifyResult {
+	var failed []VerifyResult
+	for _, r := range b.Results {
+		if r.Verify != nil && r.Verify.Failed() {
+			failed = append(failed, *r.Verify)
+		}
+	}
+	return failed
+}
+
+// Options tune how RunAll treats the live files
+type Options struct {
+	OnConflict ConflictPolicy // what to do with files edited outside palettesmith
+	Theme      string         // theme being applied, for the journal
+	Journal    string         // history file to record the apply in; empty skips it
+}
+
+// Run applies a single plugin with default options; see RunAll
+func Run(cfg config.Config, plug plugin.Plugin, vals map[string]string) (Result, error) {
+	batch, err := RunAll(cfg, []Target{{Plugin: plug, Values: vals}}, Options{})
+	if len(batch.Results) == 0 {
+		return Result{PluginID: plug.Manifest.ID}, err
+	}
+	return batch.Results[0], err
+}
+
+// RunAll validates and renders every target into the staging directory and
+// promotes all of them as one transaction: if any target fails to validate
+// or render, nothing is promoted. A target whose verify command rejects its
+// staged files is left out and reported on its result. Live files edited
+// since palettesmith last wrote them are handled according to
+// opts.OnConflict; by default the apply stops with a *ConflictError.
+func RunAll(cfg config.Config, targets []Target, opts Options) (Batch, error) {
+	var batch Batch
+	stagingDir := cfg.StagingDir
+	if stagingDir == "" {
+		return batch, errors.New("staging directory is not configured")
+	}
+	if len(targets) == 0 {
+		return batch, errors.New("no targets to apply")
+	}
+
+	var all []Output
+	owners := map[string]string{}
+	manifests := map[string]plugin.Manifest{}
+	for _, t := range targets {
+		id := t.Plugin.Manifest.ID
+		manifests[id] = t.Plugin.Manifest
+		if err := validate(t); err != nil {
+			return batch, fmt.Errorf("invalid values for '%s': %w", id, err)
+		}
+		outs, err := Render(t.Plugin, t.Values)
+		if err != nil {
+			return batch, fmt.Errorf("failed to render '%s': %w", id, err)
+		}
+		for _, out := range outs {
+			if other, ok := owners[out.Path]; ok {
+				return batch, fmt.Errorf("'%s' and '%s' both write %s", other, id, out.Path)
+			}
+			owners[out.Path] = id
+		}
+		all = append(all, outs...)
+	}
+	all, err := intoRecordedBlocks(stagingDir, all)
+	if err != nil {
+		return batch, fmt.Errorf("failed to apply: %w", err)
+	}
+	if err := checkOwned(stagingDir, all); err != nil {
+		return batch, fmt.Errorf("failed to apply: %w", err)
+	}
+
+	// Files that already hold the rendered content are neither rewritten nor
+	// reloaded, so switching between similar themes only touches what differs
+	changed, unchanged, err := splitUnchanged(all)
+	if err != nil {
+		return batch, fmt.Errorf("failed to read live files: %w", err)
+	}
+	write, err := resolveConflicts(stagingDir, changed, opts.OnConflict, manifests)
+	if err != nil {
+		return batch, err
+	}
+
+	// Verify commands see the staged files; targets they reject are dropped
+	// before anything is backed up or promoted
+	var staged []string
+	var verified map[string]VerifyResult
+	if len(write) > 0 {
+		staged, err = stage(stagingDir, write)
+		if err != nil {
+			return batch, fmt.Errorf("failed to apply: failed to stage: %w", err)
+		}
+		write, staged, verified = verifyStaged(manifests, write, staged)
+		if len(write) == 0 {
+			_ = os.RemoveAll(filepath.Join(stagingDir, nextDirName))
+		}
+	}
+
+	// Once files are promoted, bookkeeping failures are returned at the end
+	// so the reloads and the journal still happen
+	var late []error
+	if len(write) > 0 {
+		gen, err := commitStaged(stagingDir, staged, write)
+		if err != nil {
+			return batch, fmt.Errorf("failed to apply: %w", err)
+		}
+		batch.Backup = gen.ID
+
+		livePaths := make([]string, 0, len(write))
+		regions := make(map[string]Region, len(write))
+		for _, out := range write {
+			livePaths = append(livePaths, out.Path)
+			regions[out.Path] = out.Region
+		}
+		if err := recordHashes(stagingDir, livePaths, regions); err != nil {
+			late = append(late, fmt.Errorf("applied, but failed to record file hashes: %w", err))
+		}
+
+		// Retention is best effort; a failed prune must not fail the apply
+		_ = Prune(stagingDir, cfg.BackupsToKeep())
+	}
+
+	written := make(map[string]bool, len(write))
+	for _, out := range write {
+		written[out.Path] = true
+	}
+	same := make(map[string]bool, len(unchanged))
+	for _, out := range unchanged {
+		same[out.Path] = true
+	}
+	for _, t := range targets {
+		res := Result{PluginID: t.Plugin.Manifest.ID, Backup: batch.Backup}
+		if vr, ok := verified[res.PluginID]; ok {
+			res.Verify = &vr
+		}
+		for _, out := range all {
+			if out.PluginID != res.PluginID {
+				continue
+			}
+			if same[out.Path] {
+				res.Unchanged = append(res.Unchanged, out.Path)
+				continue
+			}
+			if res.Verify != nil && res.Verify.Failed() {
+				continue
+			}
+			if !written[out.Path] {
+				res.Skipped = append(res.Skipped, out.Path)
+				continue
+			}
+			res.Written = append(res.Written, out.Path)
+			res.Warnings = append(res.Warnings, out.Warnings...)
+		}
+		batch.Results = append(batch.Results, res)
+	}
+
+	// The files are live at this point, so a failed reload is reported on
+	// the result rather than as an apply error
+	var reload []plugin.Manifest
+	for i, res := range batch.Results {
+		if len(res.Written) > 0 {
+			reload = append(reload, targets[i].Plugin.Manifest)
+		}
+	}
+	reloaded := map[string]ReloadResult{}
+	for _, rr := range ReloadAll(context.Background(), reload, DefaultReloadWorkers) {
+		reloaded[rr.PluginID] = rr
+	}
+	for i := range batch.Results {
+		if rr, ok := reloaded[batch.Results[i].PluginID]; ok {
+			batch.Results[i].Reload = &rr
+		}
+	}
+
+	if opts.Journal != "" && len(write) > 0 {
+		if err := history.Append(opts.Journal, journalEntry(opts.Theme, batch, write)); err != nil {
+			late = append(late, fmt.Errorf("applied, but failed to record history: %w", err))
+		}
+	}
+	return batch, errors.Join(late...)
+}
+
+// splitUnchanged separates outputs that would change their live file from
+// those whose live file already holds exactly the rendered content
+func splitUnchanged(outs []Output) (changed, unchanged []Output, err error) {
+	for _, out := range outs {
+		live, err := os.ReadFile(out.Path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			changed = append(changed, out)
+		case err != nil:
+			return nil, nil, err
+		case bytes.Equal(live, out.Content):
+			unchanged = append(unchanged, out)
+		default:
+			changed = append(changed, out)
+		}
+	}
+	return changed, unchanged, nil
+}
+
+// validate checks every resolved value against its field spec
+func validate(t Target) error {
+	for _, f := range t.Plugin.Spec.Fields {
+		if err := f.Validate(t.Values[f.Key]); err != nil {
+			return fmt.Errorf("field '%s': %s", f.Key, err)
+		}
+	}
+	return nil
+}
+
+// errNoUserConfig means neither a user nor a system config file exists
+var errNoUserConfig = errors.New("no existing config file")
+
+// userConfig locates the config file a plugin edits in place: the first
+// existing user path or, when the user has no config yet, the first existing
+// system path copied to the first user path. existed reports whether the
+// returned path already exists on disk.
+func userConfig(m plugin.Manifest) (path string, content []byte, existed bool, err error) {
+	if p, ok, err := paths.FirstExisting(m.UserPaths); err != nil {
+		return "", nil, false, err
+	} else if ok {
+		data, err := os.ReadFile(p)
+		return p, data, true, err
+	}
+
+	if len(m.UserPaths) == 0 {
+		return "", nil, false, fmt.Errorf("plugin '%s' declares no user paths", m.ID)
+	}
+	dest, err := paths.WriteTarget(m.UserPaths[0])
+	if err != nil {
+		return "", nil, false, err
+	}
+	sys, ok, err := paths.FirstExisting(m.SystemPaths)
+	if err != nil {
+		return "", nil, false, err
+	}
+	if !ok {
+		return dest, nil, false, fmt.Errorf("%w: none of %s exist", errNoUserConfig, strings.Join(append(m.UserPaths, m.SystemPaths...), ", "))
+	}
+	data, err := os.ReadFile(sys)
+	return dest, data, false, err
+}
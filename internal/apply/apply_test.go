@@ -0,0 +1,274 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"palettesmith/internal/config"
+	"palettesmith/internal/history"
+	"palettesmith/internal/plugin"
+	"palettesmith/internal/theme"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPlugin(userPath string) plugin.Plugin {
+	return plugin.Plugin{
+		Manifest: plugin.Manifest{ID: "demo", UserPaths: []string{userPath}},
+		Spec: plugin.Spec{ID: "demo", Fields: []plugin.Field{
+			{Key: "bg", Type: "color", Default: "#000000"},
+			{Key: "fg", Type: "color", Default: "#ffffff"},
+		}},
+	}
+}
+
+func TestValues(t *testing.T) {
+	t.Run("should_resolve_override_then_theme_then_field_default", func(t *testing.T) {
+		th := theme.NewStore(theme.ThemeConfig{ThemeDefaults: map[string]string{"bg": "#111111"}})
+		th.SetOverride("demo", "fg", "#222222")
+
+		vals := Values(testPlugin("/tmp/x"), th)
+
+		assert.Equal(t, "#111111", vals["bg"])
+		assert.Equal(t, "#222222", vals["fg"])
+	})
+
+	t.Run("should_fall_back_to_field_defaults_without_store", func(t *testing.T) {
+		vals := Values(testPlugin("/tmp/x"), nil)
+
+		assert.Equal(t, "#000000", vals["bg"])
+		assert.Equal(t, "#ffffff", vals["fg"])
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("should_stage_and_promote_rendered_file", func(t *testing.T) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "live", "app.conf")
+		staging := filepath.Join(dir, "staging")
+
+		res, err := Run(config.Config{StagingDir: staging}, testPlugin(live), map[string]string{"bg": "#101010", "fg": "#efefef"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{live}, res.Written)
+		assert.NotEmpty(t, res.Backup)
+
+		data, err := os.ReadFile(live)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "bg = #101010\n")
+		assert.Contains(t, string(data), "fg = #efefef\n")
+	})
+
+	t.Run("should_fail_without_user_paths", func(t *testing.T) {
+		plug := testPlugin("")
+		plug.Manifest.UserPaths = nil
+
+		_, err := Run(config.Config{StagingDir: t.TempDir()}, plug, map[string]string{"bg": "#000000", "fg": "#ffffff"})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no user paths")
+	})
+
+	t.Run("should_fail_without_staging_dir", func(t *testing.T) {
+		_, err := Run(config.Config{}, testPlugin("/tmp/x"), map[string]string{})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestUserConfig(t *testing.T) {
+	t.Run("should_prefer_an_existing_user_path", func(t *testing.T) {
+		dir := t.TempDir()
+		user := filepath.Join(dir, "user.conf")
+		sys := filepath.Join(dir, "system.conf")
+		require.NoError(t, os.WriteFile(user, []byte("mine"), 0o644))
+		require.NoError(t, os.WriteFile(sys, []byte("vendor"), 0o644))
+
+		p, data, existed, err := userConfig(plugin.Manifest{UserPaths: []string{user}, SystemPaths: []string{sys}})
+
+		require.NoError(t, err)
+		assert.Equal(t, user, p)
+		assert.Equal(t, "mine", string(data))
+		assert.True(t, existed)
+	})
+
+	t.Run("should_seed_first_user_path_from_system_path", func(t *testing.T) {
+		dir := t.TempDir()
+		user := filepath.Join(dir, "home", "app.conf")
+		sys := filepath.Join(dir, "etc", "app.conf")
+		require.NoError(t, os.MkdirAll(filepath.Dir(sys), 0o755))
+		require.NoError(t, os.WriteFile(sys, []byte("vendor"), 0o644))
+
+		p, data, existed, err := userConfig(plugin.Manifest{UserPaths: []string{user}, SystemPaths: []string{sys}})
+
+		require.NoError(t, err)
+		assert.Equal(t, user, p)
+		assert.Equal(t, "vendor", string(data))
+		assert.False(t, existed)
+	})
+
+	t.Run("should_report_missing_config", func(t *testing.T) {
+		dir := t.TempDir()
+
+		_, _, _, err := userConfig(plugin.Manifest{UserPaths: []string{filepath.Join(dir, "a.conf")}})
+
+		assert.ErrorIs(t, err, errNoUserConfig)
+	})
+}
+
+func TestRunAll(t *testing.T) {
+	t.Run("should_apply_every_target_under_one_backup", func(t *testing.T) {
+		dir := t.TempDir()
+		a := testPlugin(filepath.Join(dir, "a.conf"))
+		b := testPlugin(filepath.Join(dir, "b.conf"))
+		b.Manifest.ID = "other"
+		vals := map[string]string{"bg": "#000000", "fg": "#ffffff"}
+
+		batch, err := RunAll(config.Config{StagingDir: filepath.Join(dir, "staging")}, []Target{
+			{Plugin: a, Values: vals},
+			{Plugin: b, Values: vals},
+		}, Options{})
+
+		require.NoError(t, err)
+		require.Len(t, batch.Results, 2)
+		assert.Equal(t, "demo, other", batch.Targets())
+		assert.Equal(t, batch.Backup, batch.Results[1].Backup)
+		assert.FileExists(t, filepath.Join(dir, "a.conf"))
+		assert.FileExists(t, filepath.Join(dir, "b.conf"))
+
+		gens, err := Generations(filepath.Join(dir, "staging"))
+		require.NoError(t, err)
+		assert.Len(t, gens, 1)
+	})
+
+	t.Run("should_promote_nothing_when_one_target_is_invalid", func(t *testing.T) {
+		dir := t.TempDir()
+		a := testPlugin(filepath.Join(dir, "a.conf"))
+		b := testPlugin(filepath.Join(dir, "b.conf"))
+		b.Manifest.ID = "other"
+
+		_, err := RunAll(config.Config{StagingDir: filepath.Join(dir, "staging")}, []Target{
+			{Plugin: a, Values: map[string]string{"bg": "#000000", "fg": "#ffffff"}},
+			{Plugin: b, Values: map[string]string{"bg": "black", "fg": "#ffffff"}},
+		}, Options{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field 'bg'")
+		assert.NoFileExists(t, filepath.Join(dir, "a.conf"))
+		assert.NoFileExists(t, filepath.Join(dir, "b.conf"))
+	})
+
+	t.Run("should_reject_targets_writing_the_same_file", func(t *testing.T) {
+		dir := t.TempDir()
+		a := testPlugin(filepath.Join(dir, "a.conf"))
+		b := testPlugin(filepath.Join(dir, "a.conf"))
+		b.Manifest.ID = "other"
+		vals := map[string]string{"bg": "#000000", "fg": "#ffffff"}
+
+		_, err := RunAll(config.Config{StagingDir: filepath.Join(dir, "staging")}, []Target{
+			{Plugin: a, Values: vals},
+			{Plugin: b, Values: vals},
+		}, Options{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "both write")
+	})
+
+	t.Run("should_record_the_apply_in_the_journal", func(t *testing.T) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "a.conf")
+		journal := filepath.Join(dir, "history.jsonl")
+
+		batch, err := RunAll(config.Config{StagingDir: filepath.Join(dir, "staging")}, []Target{
+			{Plugin: testPlugin(live), Values: map[string]string{"bg": "#000000", "fg": "#ffffff"}},
+		}, Options{Theme: "nord", Journal: journal})
+
+		require.NoError(t, err)
+		entries, err := history.Read(journal)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "nord", entries[0].Theme)
+		assert.Equal(t, []string{"demo"}, entries[0].Targets)
+		assert.Equal(t, batch.Backup, entries[0].Backup)
+		require.Len(t, entries[0].Files, 1)
+		assert.Equal(t, live, entries[0].Files[0].Path)
+		assert.Equal(t, hashContent([]byte(readString(t, live))), entries[0].Files[0].SHA256)
+	})
+
+	t.Run("should_still_reload_and_journal_when_hashes_cannot_be_recorded", func(t *testing.T) {
+		dir := t.TempDir()
+		staging := filepath.Join(dir, "staging")
+		journal := filepath.Join(dir, "history.jsonl")
+		log := filepath.Join(dir, "reloads")
+		plug := testPlugin(filepath.Join(dir, "a.conf"))
+		plug.Manifest.Reload = logReload(log, "demo", "0")
+		// Runs after the conflict check, so only recording the hashes fails
+		plug.Manifest.Verify = []string{"mkdir", filepath.Join(staging, hashesFile)}
+
+		batch, err := RunAll(config.Config{StagingDir: staging}, []Target{
+			{Plugin: plug, Values: map[string]string{"bg": "#000000", "fg": "#ffffff"}},
+		}, Options{Journal: journal})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to record file hashes")
+		require.NotNil(t, batch.Results[0].Reload)
+		assert.Equal(t, "demo\n", readString(t, log))
+		entries, err := history.Read(journal)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+}
+
+func TestRunAll_Unchanged(t *testing.T) {
+	t.Run("should_skip_writes_and_reloads_when_nothing_changed", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg := config.Config{StagingDir: filepath.Join(dir, "staging")}
+		log := filepath.Join(dir, "reloads")
+		plug := testPlugin(filepath.Join(dir, "a.conf"))
+		plug.Manifest.Reload = logReload(log, "demo", "0")
+		vals := map[string]string{"bg": "#000000", "fg": "#ffffff"}
+		_, err := RunAll(cfg, []Target{{Plugin: plug, Values: vals}}, Options{})
+		require.NoError(t, err)
+
+		batch, err := RunAll(cfg, []Target{{Plugin: plug, Values: vals}}, Options{})
+
+		require.NoError(t, err)
+		assert.Empty(t, batch.Backup)
+		assert.Empty(t, batch.Results[0].Written)
+		assert.Equal(t, []string{filepath.Join(dir, "a.conf")}, batch.Results[0].Unchanged)
+		assert.Nil(t, batch.Results[0].Reload)
+		assert.Equal(t, "demo\n", readString(t, log), "only the first apply reloads")
+
+		gens, err := Generations(cfg.StagingDir)
+		require.NoError(t, err)
+		assert.Len(t, gens, 1)
+	})
+
+	t.Run("should_reload_only_targets_whose_files_changed", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg := config.Config{StagingDir: filepath.Join(dir, "staging")}
+		log := filepath.Join(dir, "reloads")
+		a := testPlugin(filepath.Join(dir, "a.conf"))
+		a.Manifest.Reload = logReload(log, "a", "0")
+		b := testPlugin(filepath.Join(dir, "b.conf"))
+		b.Manifest.ID = "other"
+		b.Manifest.Reload = logReload(log, "b", "0")
+		vals := map[string]string{"bg": "#000000", "fg": "#ffffff"}
+		_, err := RunAll(cfg, []Target{{Plugin: a, Values: vals}, {Plugin: b, Values: vals}}, Options{})
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(log))
+
+		batch, err := RunAll(cfg, []Target{
+			{Plugin: a, Values: vals},
+			{Plugin: b, Values: map[string]string{"bg": "#111111", "fg": "#ffffff"}},
+		}, Options{})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, batch.Backup)
+		assert.Nil(t, batch.Results[0].Reload)
+		require.NotNil(t, batch.Results[1].Reload)
+		assert.Equal(t, "b\n", readString(t, log))
+	})
+}
@@ -0,0 +1,141 @@
+package apply
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"palettesmith/internal/paths"
+	"palettesmith/internal/plugin"
+	"strings"
+)
+
+// blockMarkers are the comment lines delimiting a managed block
+type blockMarkers struct {
+	begin, end string
+}
+
+// markersFor builds the begin/end lines using the plugin's comment syntax
+func markersFor(m plugin.Manifest) blockMarkers {
+	open := m.Comment
+	if open == "" {
+		open = "#"
+	}
+	line := func(tag string) string {
+		s := open + " palettesmith:" + tag
+		if m.CommentEnd != "" {
+			s += " " + m.CommentEnd
+		}
+		return s
+	}
+	return blockMarkers{begin: line("begin"), end: line("end")}
+}
+
+// region is the managed block as a hashed region of the file
+func (mk blockMarkers) region() Region {
+	return Region{Begin: mk.begin, End: mk.end}
+}
+
+// findBlock returns the line indexes of the first managed block's markers,
+// or -1 for a marker that is missing
+func findBlock(lines []string, mk blockMarkers) (int, int) {
+	begin, end := -1, -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case begin < 0 && trimmed == mk.begin:
+			begin = i
+		case begin >= 0 && trimmed == mk.end:
+			return begin, i
+		}
+	}
+	return begin, end
+}
+
+// renderBlock places the plugin's content in a managed block. With templates,
+// each rendered template goes into a block inside its output path; otherwise
+// the fields are written as "key = value" lines into the first user path.
+func renderBlock(plug plugin.Plugin, vals map[string]string) ([]Output, error) {
+	mk := markersFor(plug.Manifest)
+
+	if len(plug.Manifest.Templates) == 0 {
+		dest, existing, _, err := userConfig(plug.Manifest)
+		// No config at all yet: create the first user path with just the block
+		if err != nil && !errors.Is(err, errNoUserConfig) {
+			return nil, err
+		}
+		content, err := upsertBlock(existing, []byte(keyValueLines(plug, vals)), mk)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dest, err)
+		}
+		return []Output{{PluginID: plug.Manifest.ID, Path: dest, Content: content, Region: mk.region()}}, nil
+	}
+
+	var outs []Output
+	for _, src := range templateSources(plug.Manifest) {
+		dest, err := paths.WriteTarget(plug.Manifest.Templates[src])
+		if err != nil {
+			return nil, err
+		}
+		body, err := renderTemplate(plug.Manifest, src, vals)
+		if err != nil {
+			return nil, err
+		}
+		out, err := blockOutput(plug.Manifest.ID, dest, body, mk)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, out)
+	}
+	return outs, nil
+}
+
+// blockOutput reads the current file at dest and upserts the block into it
+func blockOutput(pluginID, dest string, body []byte, mk blockMarkers) (Output, error) {
+	existing, err := os.ReadFile(dest)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Output{}, err
+	}
+	content, err := upsertBlock(existing, body, mk)
+	if err != nil {
+		return Output{}, fmt.Errorf("%s: %w", dest, err)
+	}
+	return Output{PluginID: pluginID, Path: dest, Content: content, Region: mk.region()}, nil
+}
+
+// upsertBlock replaces the contents of the managed block in existing, or
+// appends a new block when there is none. Bytes outside the block are kept.
+func upsertBlock(existing, body []byte, mk blockMarkers) ([]byte, error) {
+	inner := string(body)
+	if inner != "" && !strings.HasSuffix(inner, "\n") {
+		inner += "\n"
+	}
+
+	lines := strings.SplitAfter(string(existing), "\n")
+	begin, end := findBlock(lines, mk)
+	if begin >= 0 && end < 0 {
+		return nil, fmt.Errorf("managed block opened by %q is never closed by %q", mk.begin, mk.end)
+	}
+
+	var b strings.Builder
+	if begin < 0 {
+		b.Write(existing)
+		if len(existing) > 0 {
+			if !strings.HasSuffix(string(existing), "\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(mk.begin + "\n" + inner + mk.end + "\n")
+		return []byte(b.String()), nil
+	}
+
+	for _, line := range lines[:begin+1] {
+		b.WriteString(line)
+	}
+	b.WriteString(inner)
+	for _, line := range lines[end:] {
+		b.WriteString(line)
+	}
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,106 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"palettesmith/internal/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkersFor(t *testing.T) {
+	t.Run("should_default_to_hash_comments", func(t *testing.T) {
+		mk := markersFor(plugin.Manifest{})
+
+		assert.Equal(t, "# palettesmith:begin", mk.begin)
+		assert.Equal(t, "# palettesmith:end", mk.end)
+	})
+
+	t.Run("should_support_block_comments", func(t *testing.T) {
+		mk := markersFor(plugin.Manifest{Comment: "/*", CommentEnd: "*/"})
+
+		assert.Equal(t, "/* palettesmith:begin */", mk.begin)
+		assert.Equal(t, "/* palettesmith:end */", mk.end)
+	})
+}
+
+func TestUpsertBlock(t *testing.T) {
+	mk := markersFor(plugin.Manifest{})
+
+	t.Run("should_append_block_on_first_apply", func(t *testing.T) {
+		out, err := upsertBlock([]byte("font_size 11"), []byte("background #000000"), mk)
+
+		require.NoError(t, err)
+		assert.Equal(t, "font_size 11\n\n# palettesmith:begin\nbackground #000000\n# palettesmith:end\n", string(out))
+	})
+
+	t.Run("should_create_block_in_empty_file", func(t *testing.T) {
+		out, err := upsertBlock(nil, []byte("a = 1\n"), mk)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# palettesmith:begin\na = 1\n# palettesmith:end\n", string(out))
+	})
+
+	t.Run("should_replace_only_block_contents", func(t *testing.T) {
+		src := "before\n  # palettesmith:begin\nold 1\nold 2\n  # palettesmith:end\nafter\n"
+
+		out, err := upsertBlock([]byte(src), []byte("new\n"), mk)
+
+		require.NoError(t, err)
+		assert.Equal(t, "before\n  # palettesmith:begin\nnew\n  # palettesmith:end\nafter\n", string(out))
+	})
+
+	t.Run("should_be_idempotent", func(t *testing.T) {
+		once, err := upsertBlock([]byte("keep me\n"), []byte("a = 1\n"), mk)
+		require.NoError(t, err)
+
+		twice, err := upsertBlock(once, []byte("a = 1\n"), mk)
+
+		require.NoError(t, err)
+		assert.Equal(t, string(once), string(twice))
+	})
+
+	t.Run("should_reject_unterminated_block", func(t *testing.T) {
+		_, err := upsertBlock([]byte("# palettesmith:begin\nx\n"), []byte("a\n"), mk)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "never closed")
+	})
+}
+
+func TestRenderBlock(t *testing.T) {
+	t.Run("should_render_template_into_block_of_its_output_path", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "colors.tmpl", "@define-color bg {{ .bg }};\n")
+		live := filepath.Join(dir, "style.css")
+		require.NoError(t, os.WriteFile(live, []byte("window { margin: 0; }\n"), 0o644))
+		plug := plugin.Plugin{Manifest: plugin.Manifest{
+			ID: "waybar", Dir: dir, Mode: plugin.ModeBlock,
+			Comment: "/*", CommentEnd: "*/",
+			Templates: map[string]string{"colors.tmpl": live},
+		}}
+
+		outs, err := Render(plug, map[string]string{"bg": "#1e1e2e"})
+
+		require.NoError(t, err)
+		require.Len(t, outs, 1)
+		assert.Equal(t, "window { margin: 0; }\n\n/* palettesmith:begin */\n@define-color bg #1e1e2e;\n/* palettesmith:end */\n", string(outs[0].Content))
+	})
+
+	t.Run("should_create_first_user_path_when_none_exist", func(t *testing.T) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "foot.ini")
+		plug := testPlugin(live)
+		plug.Manifest.Mode = plugin.ModeBlock
+
+		outs, err := Render(plug, map[string]string{"bg": "#000000", "fg": "#ffffff"})
+
+		require.NoError(t, err)
+		require.Len(t, outs, 1)
+		assert.Equal(t, live, outs[0].Path)
+		assert.Equal(t, "# palettesmith:begin\nbg = #000000\nfg = #ffffff\n# palettesmith:end\n", string(outs[0].Content))
+	})
+}
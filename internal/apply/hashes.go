@@ -0,0 +1,295 @@
+package apply
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"palettesmith/internal/plugin"
+	"path/filepath"
+	"strings"
+)
+
+// hashesFile records the content hash of every live file palettesmith wrote
+const hashesFile = "hashes.json"
+
+// Conflict policies for live files edited outside palettesmith
+type ConflictPolicy int
+
+const (
+	ConflictAbort      ConflictPolicy = iota // refuse to apply (the default)
+	ConflictOverwrite                        // replace the edited file anyway
+	ConflictKeepTheirs                       // leave the edited file untouched
+	ConflictMerge                            // keep the edits and put our content in a managed block
+)
+
+// Conflict is a live file that changed since palettesmith last wrote it
+type Conflict struct {
+	PluginID string
+	Path     string
+}
+
+// ConflictError is returned when an apply would overwrite external edits
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	ps := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		ps = append(ps, c.Path)
+	}
+	return fmt.Sprintf("changed outside palettesmith since the last apply: %s", strings.Join(ps, ", "))
+}
+
+// Region is the part of a live file an output manages. Files shared with
+// the user (blocks, patched lines, include directives) are only checked for
+// external edits inside it, so changes elsewhere never count as conflicts.
+// The zero value is the whole file.
+type Region struct {
+	Begin    string   `json:"begin,omitempty"` // managed block markers
+	End      string   `json:"end,omitempty"`
+	Patterns []string `json:"patterns,omitempty"` // patch patterns; every matching line
+	Lines    []string `json:"lines,omitempty"`    // include directives
+}
+
+func (r Region) whole() bool {
+	return r.Begin == "" && len(r.Patterns) == 0 && len(r.Lines) == 0
+}
+
+// extract returns the managed bytes of data. A block that is never closed
+// runs to the end of the file.
+func (r Region) extract(data []byte) ([]byte, error) {
+	if r.whole() {
+		return data, nil
+	}
+	pats := make([]linePattern, 0, len(r.Patterns))
+	for _, p := range r.Patterns {
+		lp, err := compilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		pats = append(pats, lp)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	begin, end := -1, -1
+	if r.Begin != "" {
+		begin, end = findBlock(lines, blockMarkers{begin: r.Begin, end: r.End})
+		if begin >= 0 && end < 0 {
+			end = len(lines) - 1
+		}
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if begin >= 0 && i >= begin && i <= end {
+			b.WriteString(line)
+			continue
+		}
+		body, _ := splitEOL(line)
+		if r.managesLine(body, pats) {
+			b.WriteString(line)
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+func (r Region) managesLine(line string, pats []linePattern) bool {
+	for _, lp := range pats {
+		if lp.re.MatchString(line) {
+			return true
+		}
+	}
+	for _, l := range r.Lines {
+		if strings.TrimSpace(line) == l {
+			return true
+		}
+	}
+	return false
+}
+
+// hashEntry is what hashesFile records for one live path
+type hashEntry struct {
+	Hash   string `json:"hash"`
+	Region Region `json:"region"`
+}
+
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashRegion hashes the managed part of the file at path
+func hashRegion(path string, r Region) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	managed, err := r.extract(data)
+	if err != nil {
+		return "", err
+	}
+	return hashContent(managed), nil
+}
+
+// loadHashes reads the recorded hashes, keyed by live path
+func loadHashes(stagingDir string) (map[string]hashEntry, error) {
+	data, err := os.ReadFile(filepath.Join(stagingDir, hashesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]hashEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	hashes := map[string]hashEntry{}
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", hashesFile, err)
+	}
+	return hashes, nil
+}
+
+// recordHashes stores the current hash of the managed region of each path,
+// forgetting paths that no longer exist. Paths missing from regions keep
+// the region recorded for them before.
+func recordHashes(stagingDir string, livePaths []string, regions map[string]Region) error {
+	hashes, err := loadHashes(stagingDir)
+	if err != nil {
+		return err
+	}
+	for _, p := range livePaths {
+		r, ok := regions[p]
+		if !ok {
+			r = hashes[p].Region
+		}
+		sum, err := hashRegion(p, r)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			delete(hashes, p)
+		case err != nil:
+			return err
+		default:
+			hashes[p] = hashEntry{Hash: sum, Region: r}
+		}
+	}
+	data, err := json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := writeTemp(filepath.Join(stagingDir, hashesFile), data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(stagingDir, hashesFile))
+}
+
+// findConflicts returns the outputs whose live file no longer matches the
+// hash recorded when palettesmith last wrote it, looking only at the region
+// that was hashed
+func findConflicts(stagingDir string, outs []Output) ([]Conflict, error) {
+	hashes, err := loadHashes(stagingDir)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []Conflict
+	for _, out := range outs {
+		want, ok := hashes[out.Path]
+		if !ok {
+			continue
+		}
+		got, err := hashRegion(out.Path, want.Region)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if got != want.Hash {
+			conflicts = append(conflicts, Conflict{PluginID: out.PluginID, Path: out.Path})
+		}
+	}
+	return conflicts, nil
+}
+
+// resolveConflicts applies the policy to outputs whose live file was edited
+// externally and returns the outputs that should still be written
+func resolveConflicts(stagingDir string, outs []Output, policy ConflictPolicy, manifests map[string]plugin.Manifest) ([]Output, error) {
+	conflicts, err := findConflicts(stagingDir, outs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for external edits: %w", err)
+	}
+	if len(conflicts) == 0 || policy == ConflictOverwrite {
+		return outs, nil
+	}
+	if policy == ConflictAbort {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+
+	conflicted := make(map[string]bool, len(conflicts))
+	for _, c := range conflicts {
+		conflicted[c.Path] = true
+	}
+	kept := make([]Output, 0, len(outs))
+	for _, out := range outs {
+		if !conflicted[out.Path] {
+			kept = append(kept, out)
+			continue
+		}
+		if policy == ConflictKeepTheirs {
+			continue
+		}
+		// ConflictMerge: their file stays. Outputs that manage only part of
+		// it were rendered onto their current file, so only that part
+		// changes; a whole-file output goes into a managed block, which is
+		// all later applies manage.
+		if !out.Region.whole() {
+			kept = append(kept, out)
+			continue
+		}
+		merged, err := intoBlock(out, markersFor(manifests[out.PluginID]))
+		if err != nil {
+			return nil, err
+		}
+		kept = append(kept, merged)
+	}
+	return kept, nil
+}
+
+// intoRecordedBlocks renders whole-file outputs into the managed block of
+// their live file when an earlier merge left palettesmith managing only
+// that block, so the user's lines around it survive
+func intoRecordedBlocks(stagingDir string, outs []Output) ([]Output, error) {
+	hashes, err := loadHashes(stagingDir)
+	if err != nil {
+		return nil, err
+	}
+	for i, out := range outs {
+		r := hashes[out.Path].Region
+		if !out.Region.whole() || r.Begin == "" {
+			continue
+		}
+		if outs[i], err = intoBlock(out, blockMarkers{begin: r.Begin, end: r.End}); err != nil {
+			return nil, err
+		}
+	}
+	return outs, nil
+}
+
+// intoBlock puts the content of a whole-file output into the managed block
+// of its current live file
+func intoBlock(out Output, mk blockMarkers) (Output, error) {
+	theirs, err := os.ReadFile(out.Path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Output{}, err
+	}
+	merged, err := upsertBlock(theirs, out.Content, mk)
+	if err != nil {
+		return Output{}, fmt.Errorf("%s: %w", out.Path, err)
+	}
+	out.Content = merged
+	out.Region = mk.region()
+	return out, nil
+}
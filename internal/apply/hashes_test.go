@@ -0,0 +1,162 @@
+package apply
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"palettesmith/internal/config"
+	"palettesmith/internal/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindConflicts(t *testing.T) {
+	t.Run("should_ignore_files_never_written", func(t *testing.T) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "app.conf")
+		require.NoError(t, os.WriteFile(live, []byte("mine"), 0o644))
+
+		conflicts, err := findConflicts(filepath.Join(dir, "staging"), []Output{{PluginID: "demo", Path: live}})
+
+		require.NoError(t, err)
+		assert.Empty(t, conflicts)
+	})
+
+	t.Run("should_report_files_edited_after_recording", func(t *testing.T) {
+		dir := t.TempDir()
+		staging := filepath.Join(dir, "staging")
+		live := filepath.Join(dir, "app.conf")
+		require.NoError(t, os.MkdirAll(staging, 0o755))
+		require.NoError(t, os.WriteFile(live, []byte("ours"), 0o644))
+		require.NoError(t, recordHashes(staging, []string{live}, nil))
+
+		require.NoError(t, os.WriteFile(live, []byte("theirs"), 0o644))
+		conflicts, err := findConflicts(staging, []Output{{PluginID: "demo", Path: live}})
+
+		require.NoError(t, err)
+		assert.Equal(t, []Conflict{{PluginID: "demo", Path: live}}, conflicts)
+	})
+}
+
+func TestRunAll_Conflicts(t *testing.T) {
+	vals := map[string]string{"bg": "#000000", "fg": "#ffffff"}
+	newVals := map[string]string{"bg": "#111111", "fg": "#ffffff"}
+	edited := "# Generated by palettesmith for demo. Do not edit.\nfont_size 11\n"
+
+	setup := func(t *testing.T) (config.Config, string) {
+		dir := t.TempDir()
+		cfg := config.Config{StagingDir: filepath.Join(dir, "staging")}
+		live := filepath.Join(dir, "app.conf")
+		_, err := RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: vals}}, Options{})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(live, []byte(edited), 0o644))
+		return cfg, live
+	}
+
+	t.Run("should_abort_by_default", func(t *testing.T) {
+		cfg, live := setup(t)
+
+		_, err := RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: newVals}}, Options{})
+
+		var ce *ConflictError
+		require.True(t, errors.As(err, &ce))
+		assert.Equal(t, live, ce.Conflicts[0].Path)
+		assert.Equal(t, edited, readString(t, live))
+	})
+
+	t.Run("should_overwrite_when_forced", func(t *testing.T) {
+		cfg, live := setup(t)
+
+		_, err := RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: newVals}}, Options{OnConflict: ConflictOverwrite})
+
+		require.NoError(t, err)
+		assert.Contains(t, readString(t, live), "bg = #111111")
+	})
+
+	t.Run("should_leave_their_file_when_keeping_theirs", func(t *testing.T) {
+		cfg, live := setup(t)
+
+		batch, err := RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: newVals}}, Options{OnConflict: ConflictKeepTheirs})
+
+		require.NoError(t, err)
+		assert.Empty(t, batch.Backup)
+		assert.Equal(t, []string{live}, batch.Results[0].Skipped)
+		assert.Equal(t, edited, readString(t, live))
+	})
+
+	t.Run("should_merge_into_managed_block", func(t *testing.T) {
+		cfg, live := setup(t)
+
+		_, err := RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: newVals}}, Options{OnConflict: ConflictMerge})
+
+		require.NoError(t, err)
+		got := readString(t, live)
+		assert.Contains(t, got, "font_size 11\n")
+		assert.Contains(t, got, "# palettesmith:begin\n")
+		assert.Contains(t, got, "bg = #111111")
+
+		// Only the block is ours now, so the next apply goes through and
+		// keeps their lines
+		_, err = RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: vals}}, Options{})
+		require.NoError(t, err)
+		got = readString(t, live)
+		assert.Contains(t, got, "font_size 11\n")
+		assert.Contains(t, got, "bg = #000000")
+		assert.NotContains(t, got, "bg = #111111")
+	})
+
+	t.Run("should_keep_their_lines_around_a_merged_template_output", func(t *testing.T) {
+		dir := t.TempDir()
+		cfg := config.Config{StagingDir: filepath.Join(dir, "staging")}
+		live := filepath.Join(dir, "colors.conf")
+		writeTemplate(t, dir, "colors.tmpl", "background {{ .bg }}\n")
+		plug := testPlugin(live)
+		plug.Manifest.Dir = dir
+		plug.Manifest.Templates = map[string]string{"colors.tmpl": live}
+		_, err := RunAll(cfg, []Target{{Plugin: plug, Values: vals}}, Options{})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(live, []byte("my own stuff\n"), 0o644))
+		_, err = RunAll(cfg, []Target{{Plugin: plug, Values: newVals}}, Options{OnConflict: ConflictMerge})
+		require.NoError(t, err)
+
+		_, err = RunAll(cfg, []Target{{Plugin: plug, Values: vals}}, Options{})
+
+		require.NoError(t, err)
+		assert.Equal(t, "my own stuff\n\n# palettesmith:begin\nbackground #000000\n# palettesmith:end\n", readString(t, live))
+	})
+
+	blockSetup := func(t *testing.T) (config.Config, plugin.Plugin, string) {
+		dir := t.TempDir()
+		cfg := config.Config{StagingDir: filepath.Join(dir, "staging")}
+		live := filepath.Join(dir, "foot.ini")
+		require.NoError(t, os.WriteFile(live, []byte("font_size 11\n"), 0o644))
+		plug := testPlugin(live)
+		plug.Manifest.Mode = plugin.ModeBlock
+		_, err := RunAll(cfg, []Target{{Plugin: plug, Values: vals}}, Options{})
+		require.NoError(t, err)
+		return cfg, plug, live
+	}
+
+	t.Run("should_ignore_edits_outside_a_managed_block", func(t *testing.T) {
+		cfg, plug, live := blockSetup(t)
+		require.NoError(t, os.WriteFile(live, []byte("font_size 12\n"+readString(t, live)+"cursor_blink no\n"), 0o644))
+
+		_, err := RunAll(cfg, []Target{{Plugin: plug, Values: newVals}}, Options{})
+
+		require.NoError(t, err)
+		assert.Equal(t, "font_size 12\nfont_size 11\n\n# palettesmith:begin\nbg = #111111\nfg = #ffffff\n# palettesmith:end\ncursor_blink no\n", readString(t, live))
+	})
+
+	t.Run("should_merge_only_the_block_of_a_block_output", func(t *testing.T) {
+		cfg, plug, live := blockSetup(t)
+		require.NoError(t, os.WriteFile(live, []byte("font_size 11\n\n# palettesmith:begin\nbg = #abcdef\n# palettesmith:end\n"), 0o644))
+
+		_, err := RunAll(cfg, []Target{{Plugin: plug, Values: newVals}}, Options{OnConflict: ConflictMerge})
+
+		require.NoError(t, err)
+		assert.Equal(t, "font_size 11\n\n# palettesmith:begin\nbg = #111111\nfg = #ffffff\n# palettesmith:end\n", readString(t, live))
+	})
+}
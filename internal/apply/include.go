@@ -0,0 +1,74 @@
+package apply
+
+import (
+	"errors"
+	"palettesmith/internal/plugin"
+	"path/filepath"
+	"strings"
+)
+
+// renderInclude renders the plugin templates as generated files and makes
+// sure the user's config includes each of them
+func renderInclude(plug plugin.Plugin, vals map[string]string) ([]Output, error) {
+	m := plug.Manifest
+	if m.Include == "" {
+		return nil, errors.New("include mode needs an include directive")
+	}
+	if len(m.Templates) == 0 {
+		return nil, errors.New("include mode needs at least one template")
+	}
+
+	outs, err := renderTemplates(plug, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	host, data, existed, err := userConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	content := data
+	var directives []string
+	for _, out := range outs {
+		directive, err := includeDirective(m.Include, host, out.Path)
+		if err != nil {
+			return nil, err
+		}
+		content = ensureLine(content, directive, m.IncludeAt == "start")
+		directives = append(directives, directive)
+	}
+
+	// Leave the user's file out of the apply entirely when it already includes everything
+	if !existed || string(content) != string(data) {
+		outs = append(outs, Output{PluginID: m.ID, Path: host, Content: content, Region: Region{Lines: directives}})
+	}
+	return outs, nil
+}
+
+// includeDirective fills {path} with the generated file's absolute path and
+// {relpath} with its path relative to the including file
+func includeDirective(tmpl, host, generated string) (string, error) {
+	rel, err := filepath.Rel(filepath.Dir(host), generated)
+	if err != nil {
+		return "", err
+	}
+	return strings.NewReplacer("{path}", generated, "{relpath}", filepath.ToSlash(rel)).Replace(tmpl), nil
+}
+
+// ensureLine adds line to content unless an identical line already exists
+func ensureLine(content []byte, line string, atStart bool) []byte {
+	for _, l := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(l) == line {
+			return content
+		}
+	}
+	if atStart {
+		return []byte(line + "\n" + string(content))
+	}
+	s := string(content)
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	return []byte(s + line + "\n")
+}
@@ -0,0 +1,89 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"palettesmith/internal/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func includePlugin(t *testing.T, dir, host string) plugin.Plugin {
+	t.Helper()
+	writeTemplate(t, dir, "colors.tmpl", "$accent = {{ .accent }}\n")
+	return plugin.Plugin{Manifest: plugin.Manifest{
+		ID:        "hyprland",
+		Dir:       dir,
+		Mode:      plugin.ModeInclude,
+		Include:   "source = {path}",
+		UserPaths: []string{host},
+		Templates: map[string]string{"colors.tmpl": filepath.Join(dir, "hypr", "palettesmith.conf")},
+	}}
+}
+
+func TestRenderInclude(t *testing.T) {
+	t.Run("should_write_generated_file_and_add_directive", func(t *testing.T) {
+		dir := t.TempDir()
+		host := filepath.Join(dir, "hypr", "hyprland.conf")
+		require.NoError(t, os.MkdirAll(filepath.Dir(host), 0o755))
+		require.NoError(t, os.WriteFile(host, []byte("monitor = , preferred, auto, 1"), 0o644))
+
+		outs, err := Render(includePlugin(t, dir, host), map[string]string{"accent": "#89b4fa"})
+
+		require.NoError(t, err)
+		require.Len(t, outs, 2)
+		generated := filepath.Join(dir, "hypr", "palettesmith.conf")
+		assert.Equal(t, generated, outs[0].Path)
+		assert.Equal(t, "$accent = #89b4fa\n", string(outs[0].Content))
+		assert.Equal(t, host, outs[1].Path)
+		assert.Equal(t, "monitor = , preferred, auto, 1\nsource = "+generated+"\n", string(outs[1].Content))
+	})
+
+	t.Run("should_leave_user_config_alone_when_directive_exists", func(t *testing.T) {
+		dir := t.TempDir()
+		host := filepath.Join(dir, "hypr", "hyprland.conf")
+		generated := filepath.Join(dir, "hypr", "palettesmith.conf")
+		require.NoError(t, os.MkdirAll(filepath.Dir(host), 0o755))
+		require.NoError(t, os.WriteFile(host, []byte("  source = "+generated+"\n"), 0o644))
+
+		outs, err := Render(includePlugin(t, dir, host), map[string]string{"accent": "#89b4fa"})
+
+		require.NoError(t, err)
+		require.Len(t, outs, 1)
+		assert.Equal(t, generated, outs[0].Path)
+	})
+
+	t.Run("should_require_an_existing_user_config", func(t *testing.T) {
+		dir := t.TempDir()
+
+		_, err := Render(includePlugin(t, dir, filepath.Join(dir, "missing.conf")), map[string]string{"accent": "#89b4fa"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIncludeDirective(t *testing.T) {
+	t.Run("should_fill_relative_path", func(t *testing.T) {
+		d, err := includeDirective(`@import "{relpath}";`, "/home/u/.config/waybar/style.css", "/home/u/.config/waybar/colors/palettesmith.css")
+
+		require.NoError(t, err)
+		assert.Equal(t, `@import "colors/palettesmith.css";`, d)
+	})
+}
+
+func TestEnsureLine(t *testing.T) {
+	t.Run("should_prepend_when_asked", func(t *testing.T) {
+		out := ensureLine([]byte("window {}\n"), `@import "a.css";`, true)
+
+		assert.Equal(t, "@import \"a.css\";\nwindow {}\n", string(out))
+	})
+
+	t.Run("should_be_idempotent", func(t *testing.T) {
+		once := ensureLine([]byte("a\n"), "include b", false)
+
+		assert.Equal(t, string(once), string(ensureLine(once, "include b", false)))
+	})
+}
@@ -0,0 +1,40 @@
+package apply
+
+import (
+	"palettesmith/internal/history"
+	"time"
+)
+
+// journalEntry describes a finished apply for the history journal
+func journalEntry(themeName string, batch Batch, written []Output) history.Entry {
+	e := history.Entry{
+		Time:   time.Now().UTC(),
+		Theme:  themeName,
+		Backup: batch.Backup,
+	}
+	for _, r := range batch.Results {
+		if len(r.Written) > 0 {
+			e.Targets = append(e.Targets, r.PluginID)
+		}
+		if r.Reload != nil {
+			e.Reloads = append(e.Reloads, journalReload(*r.Reload))
+		}
+	}
+	for _, out := range written {
+		e.Files = append(e.Files, history.File{Target: out.PluginID, Path: out.Path, SHA256: hashContent(out.Content)})
+	}
+	return e
+}
+
+func journalReload(r ReloadResult) history.Reload {
+	rec := history.Reload{
+		Target:     r.PluginID,
+		Command:    r.Command,
+		ExitCode:   r.ExitCode,
+		DurationMS: r.Duration.Milliseconds(),
+	}
+	if r.Err != nil {
+		rec.Error = r.Err.Error()
+	}
+	return rec
+}
@@ -0,0 +1,44 @@
+package apply
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// generatedHeader starts every file palettesmith writes in full
+const generatedHeader = "# Generated by palettesmith"
+
+// checkOwned refuses whole-file outputs that would replace an existing file
+// palettesmith never wrote, so a plugin pointed at a hand-written config
+// cannot wipe it. A file counts as palettesmith's when it has a recorded
+// hash, starts with the generated header or already holds the rendered
+// content. Recorded files that were edited, even ones whose header was
+// removed, go through the conflict check instead.
+func checkOwned(stagingDir string, outs []Output) error {
+	hashes, err := loadHashes(stagingDir)
+	if err != nil {
+		return err
+	}
+	for _, out := range outs {
+		if !out.Region.whole() {
+			continue
+		}
+		if _, ok := hashes[out.Path]; ok {
+			continue
+		}
+		data, err := os.ReadFile(out.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !bytes.HasPrefix(data, []byte(generatedHeader)) && !bytes.Equal(data, out.Content) {
+			return fmt.Errorf("refusing to overwrite %s: it was not generated by palettesmith", out.Path)
+		}
+	}
+	return nil
+}
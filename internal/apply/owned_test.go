@@ -0,0 +1,76 @@
+package apply
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"palettesmith/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAll_Ownership(t *testing.T) {
+	vals := map[string]string{"bg": "#000000", "fg": "#ffffff"}
+
+	// setup creates the live file unless content is empty
+	setup := func(t *testing.T, content string) (config.Config, string) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "app.conf")
+		if content != "" {
+			require.NoError(t, os.WriteFile(live, []byte(content), 0o644))
+		}
+		return config.Config{StagingDir: filepath.Join(dir, "staging")}, live
+	}
+
+	t.Run("should_refuse_to_replace_a_hand_written_config", func(t *testing.T) {
+		cfg, live := setup(t, "exec-once = waybar\n")
+
+		_, err := RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: vals}}, Options{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not generated by palettesmith")
+		assert.Equal(t, "exec-once = waybar\n", readString(t, live))
+	})
+
+	t.Run("should_replace_a_file_it_generated", func(t *testing.T) {
+		cfg, live := setup(t, "# Generated by palettesmith for demo. Do not edit.\nbg = #111111\n")
+
+		_, err := RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: vals}}, Options{})
+
+		require.NoError(t, err)
+		assert.Contains(t, readString(t, live), "bg = #000000\n")
+	})
+
+	t.Run("should_report_a_written_file_that_lost_its_header_as_a_conflict", func(t *testing.T) {
+		cfg, live := setup(t, "")
+		_, err := RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: vals}}, Options{})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(live, []byte("bg = #abcdef\n"), 0o644))
+
+		_, err = RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: vals}}, Options{})
+
+		var ce *ConflictError
+		require.True(t, errors.As(err, &ce))
+		_, err = RunAll(cfg, []Target{{Plugin: testPlugin(live), Values: vals}}, Options{OnConflict: ConflictOverwrite})
+		require.NoError(t, err)
+		assert.Contains(t, readString(t, live), "bg = #000000\n")
+	})
+
+	t.Run("should_refuse_to_replace_a_hand_written_template_output", func(t *testing.T) {
+		cfg, live := setup(t, "window { margin: 0; }\n")
+		dir := filepath.Dir(live)
+		writeTemplate(t, dir, "colors.tmpl", "bg {{ .bg }}\n")
+		plug := testPlugin(live)
+		plug.Manifest.Dir = dir
+		plug.Manifest.Templates = map[string]string{"colors.tmpl": live}
+
+		_, err := RunAll(cfg, []Target{{Plugin: plug, Values: vals}}, Options{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not generated by palettesmith")
+		assert.Equal(t, "window { margin: 0; }\n", readString(t, live))
+	})
+}
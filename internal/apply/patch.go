@@ -0,0 +1,152 @@
+package apply
+
+import (
+	"fmt"
+	"palettesmith/internal/plugin"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// valuePlaceholder marks where a field's value sits in a patch pattern. An
+// optional filter names a template func, e.g. "rgb({value|trimHash})".
+var valuePlaceholder = regexp.MustCompile(`\{value(?:\|(\w+))?\}`)
+
+// linePattern is a compiled Field.Pattern
+type linePattern struct {
+	re     *regexp.Regexp
+	filter func(string) string
+}
+
+// compilePattern turns a field pattern into a line matcher. A space in the
+// pattern matches any run of whitespace, which must be non-empty between two
+// words ("a b" does not match "ab") but may be empty next to punctuation
+// ("key = {value}" matches "key=x"). A trailing "# comment" is never part of
+// the value.
+func compilePattern(p string) (linePattern, error) {
+	loc := valuePlaceholder.FindStringSubmatchIndex(p)
+	if loc == nil {
+		return linePattern{}, fmt.Errorf("pattern %q has no {value} placeholder", p)
+	}
+
+	lp := linePattern{filter: func(s string) string { return s }}
+	if loc[2] >= 0 {
+		name := p[loc[2]:loc[3]]
+		fn, ok := templateFuncs[name].(func(string) string)
+		if !ok {
+			return linePattern{}, fmt.Errorf("pattern %q uses unknown filter '%s'", p, name)
+		}
+		lp.filter = fn
+	}
+
+	// The value is a word for whitespace purposes; the line ends are not.
+	// It starts at a non-space so the prefix swallows all the padding, and
+	// ends before an optional whitespace-separated comment.
+	expr := `^\s*` + literalPattern(p[:loc[0]], false, true) +
+		`(\S.*?|)` +
+		literalPattern(p[loc[1]:], true, false) + `(?:\s+#.*)?\s*$`
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return linePattern{}, fmt.Errorf("invalid pattern %q: %w", p, err)
+	}
+	lp.re = re
+	return lp, nil
+}
+
+// literalPattern quotes s for a regexp, turning whitespace runs into \s+
+// between words and \s* elsewhere. wordBefore and wordAfter say whether
+// what surrounds s counts as a word.
+func literalPattern(s string, wordBefore, wordAfter bool) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i := 0; i < len(rs); i++ {
+		if rs[i] != ' ' && rs[i] != '\t' {
+			b.WriteString(regexp.QuoteMeta(string(rs[i])))
+			continue
+		}
+		j := i
+		for j < len(rs) && (rs[j] == ' ' || rs[j] == '\t') {
+			j++
+		}
+		left, right := wordBefore, wordAfter
+		if i > 0 {
+			left = isWordRune(rs[i-1])
+		}
+		if j < len(rs) {
+			right = isWordRune(rs[j])
+		}
+		if left && right {
+			b.WriteString(`\s+`)
+		} else {
+			b.WriteString(`\s*`)
+		}
+		i = j - 1
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// renderPatch rewrites the value of every line matching a field pattern in
+// the user's config, leaving all other bytes untouched
+func renderPatch(plug plugin.Plugin, vals map[string]string) ([]Output, error) {
+	dest, data, _, err := userConfig(plug.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	out := Output{PluginID: plug.Manifest.ID, Path: dest}
+	content := data
+	for _, f := range plug.Spec.Fields {
+		if f.Pattern == "" {
+			continue
+		}
+		out.Region.Patterns = append(out.Region.Patterns, f.Pattern)
+		lp, err := compilePattern(f.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %w", f.Key, err)
+		}
+		var n int
+		content, n = patchLines(content, lp, lp.filter(vals[f.Key]))
+		if n == 0 {
+			out.Warnings = append(out.Warnings, fmt.Sprintf("%s: no line matches %q", dest, f.Pattern))
+		}
+	}
+	out.Content = content
+	return []Output{out}, nil
+}
+
+// patchLines replaces the captured value on every matching line and returns
+// the new content with the number of lines changed
+func patchLines(content []byte, lp linePattern, value string) ([]byte, int) {
+	var b strings.Builder
+	b.Grow(len(content))
+	n := 0
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		body, eol := splitEOL(line)
+		loc := lp.re.FindStringSubmatchIndex(body)
+		if loc == nil {
+			b.WriteString(line)
+			continue
+		}
+		n++
+		b.WriteString(body[:loc[2]])
+		b.WriteString(value)
+		b.WriteString(body[loc[3]:])
+		b.WriteString(eol)
+	}
+	return []byte(b.String()), n
+}
+
+// splitEOL separates a line from its trailing "\n" or "\r\n"
+func splitEOL(line string) (string, string) {
+	if strings.HasSuffix(line, "\r\n") {
+		return line[:len(line)-2], "\r\n"
+	}
+	if strings.HasSuffix(line, "\n") {
+		return line[:len(line)-1], "\n"
+	}
+	return line, ""
+}
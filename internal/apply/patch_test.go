@@ -0,0 +1,131 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"palettesmith/internal/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompilePattern(t *testing.T) {
+	t.Run("should_require_a_value_placeholder", func(t *testing.T) {
+		_, err := compilePattern("col.active_border = rgb(89b4fa)")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "{value}")
+	})
+
+	t.Run("should_reject_unknown_filters", func(t *testing.T) {
+		_, err := compilePattern("key = {value|nope}")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown filter")
+	})
+}
+
+func TestPatchLines(t *testing.T) {
+	t.Run("should_rewrite_only_the_value_and_keep_other_bytes", func(t *testing.T) {
+		src := "# comment\ngeneral {\n\tcol.active_border=rgb(000000)   \n\tgaps_in = 5\n}\r\nlast"
+		lp, err := compilePattern("col.active_border = rgb({value|trimHash})")
+		require.NoError(t, err)
+
+		out, n := patchLines([]byte(src), lp, lp.filter("#89b4fa"))
+
+		assert.Equal(t, 1, n)
+		assert.Equal(t, "# comment\ngeneral {\n\tcol.active_border=rgb(89b4fa)   \n\tgaps_in = 5\n}\r\nlast", string(out))
+	})
+
+	t.Run("should_keep_a_trailing_comment", func(t *testing.T) {
+		lp, err := compilePattern("background = {value}")
+		require.NoError(t, err)
+
+		out, n := patchLines([]byte("background =  #000000   # dark\nbackground = #000000\n"), lp, "#ffffff")
+
+		assert.Equal(t, 2, n)
+		assert.Equal(t, "background =  #ffffff   # dark\nbackground = #ffffff\n", string(out))
+	})
+
+	t.Run("should_require_whitespace_between_words", func(t *testing.T) {
+		lp, err := compilePattern("font size {value}")
+		require.NoError(t, err)
+
+		_, n := patchLines([]byte("fontsize 11\nfont_size 11\n"), lp, "12")
+		assert.Equal(t, 0, n)
+
+		out, n := patchLines([]byte("font   size 11\n"), lp, "12")
+		assert.Equal(t, 1, n)
+		assert.Equal(t, "font   size 12\n", string(out))
+	})
+
+	t.Run("should_ignore_commented_lines", func(t *testing.T) {
+		lp, err := compilePattern("background = {value}")
+		require.NoError(t, err)
+
+		out, n := patchLines([]byte("# background = #000000\n"), lp, "#ffffff")
+
+		assert.Equal(t, 0, n)
+		assert.Equal(t, "# background = #000000\n", string(out))
+	})
+}
+
+func TestRenderPatch(t *testing.T) {
+	t.Run("should_patch_first_existing_user_path_and_warn_on_missing_lines", func(t *testing.T) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "kitty.conf")
+		require.NoError(t, os.WriteFile(live, []byte("font_size 11\nbackground #000000\n"), 0o644))
+		plug := plugin.Plugin{
+			Manifest: plugin.Manifest{
+				ID:        "kitty",
+				Mode:      plugin.ModePatch,
+				UserPaths: []string{filepath.Join(dir, "missing.conf"), live},
+			},
+			Spec: plugin.Spec{Fields: []plugin.Field{
+				{Key: "bg", Pattern: "background {value}"},
+				{Key: "fg", Pattern: "foreground {value}"},
+				{Key: "unused"},
+			}},
+		}
+
+		outs, err := Render(plug, map[string]string{"bg": "#1e1e2e", "fg": "#cdd6f4"})
+
+		require.NoError(t, err)
+		require.Len(t, outs, 1)
+		assert.Equal(t, live, outs[0].Path)
+		assert.Equal(t, "font_size 11\nbackground #1e1e2e\n", string(outs[0].Content))
+		require.Len(t, outs[0].Warnings, 1)
+		assert.Contains(t, outs[0].Warnings[0], "foreground {value}")
+	})
+
+	t.Run("should_fail_when_no_user_path_exists", func(t *testing.T) {
+		plug := plugin.Plugin{Manifest: plugin.Manifest{
+			ID: "kitty", Mode: plugin.ModePatch, UserPaths: []string{filepath.Join(t.TempDir(), "nope.conf")},
+		}}
+
+		_, err := Render(plug, map[string]string{})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestRenderPatch_SeedsFromSystemPath(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "home", "foot.ini")
+	sys := filepath.Join(dir, "etc", "foot.ini")
+	require.NoError(t, os.MkdirAll(filepath.Dir(sys), 0o755))
+	require.NoError(t, os.WriteFile(sys, []byte("[colors]\nbackground=000000\n"), 0o644))
+	plug := plugin.Plugin{
+		Manifest: plugin.Manifest{ID: "foot", Mode: plugin.ModePatch, UserPaths: []string{user}, SystemPaths: []string{sys}},
+		Spec:     plugin.Spec{Fields: []plugin.Field{{Key: "bg", Pattern: "background={value|trimHash}"}}},
+	}
+
+	outs, err := Render(plug, map[string]string{"bg": "#1e1e2e"})
+
+	require.NoError(t, err)
+	require.Len(t, outs, 1)
+	assert.Equal(t, user, outs[0].Path)
+	assert.Equal(t, "[colors]\nbackground=1e1e2e\n", string(outs[0].Content))
+}
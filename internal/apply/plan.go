@@ -0,0 +1,135 @@
+package apply
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"palettesmith/internal/plugin"
+	"palettesmith/internal/theme"
+)
+
+// File actions in a plan
+const (
+	ActionCreate    = "create"
+	ActionModify    = "modify"
+	ActionUnchanged = "unchanged"
+)
+
+// Plan is what an apply would do, computed without writing anything
+type Plan struct {
+	Theme   string       `json:"theme,omitempty"`
+	Targets []PlanTarget `json:"targets"`
+}
+
+// PlanTarget is the planned apply of a single plugin
+type PlanTarget struct {
+	ID       string      `json:"id"`
+	Title    string      `json:"title,omitempty"`
+	Mode     string      `json:"mode"`
+	Values   []PlanValue `json:"values"`
+	Files    []PlanFile  `json:"files,omitempty"`
+	Verify   []string    `json:"verify,omitempty"`
+	Reload   []string    `json:"reload,omitempty"`
+	Warnings []string    `json:"warnings,omitempty"`
+	Error    string      `json:"error,omitempty"` // why the target cannot be applied
+}
+
+// PlanValue is a resolved field value and the layer it came from
+type PlanValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // one of the theme.Source* constants
+}
+
+// PlanFile is a live file the apply would touch
+type PlanFile struct {
+	Path   string `json:"path"`
+	Action string `json:"action"` // one of the Action* constants
+}
+
+// Failed reports whether any target in the plan cannot be applied
+func (p Plan) Failed() bool {
+	for _, t := range p.Targets {
+		if t.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Changed reports whether applying the plan would modify any file
+func (p Plan) Changed() bool {
+	for _, t := range p.Targets {
+		for _, f := range t.Files {
+			if f.Action != ActionUnchanged {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BuildPlan resolves and renders every plugin against the theme store and
+// compares the result with the live files. Targets that fail to validate or
+// render carry the error instead of failing the whole plan.
+func BuildPlan(themeName string, plugs []plugin.Plugin, th *theme.Store) (Plan, error) {
+	plan := Plan{Theme: themeName, Targets: make([]PlanTarget, 0, len(plugs))}
+	for _, plug := range plugs {
+		pt, err := planTarget(plug, th)
+		if err != nil {
+			return plan, err
+		}
+		plan.Targets = append(plan.Targets, pt)
+	}
+	return plan, nil
+}
+
+func planTarget(plug plugin.Plugin, th *theme.Store) (PlanTarget, error) {
+	m := plug.Manifest
+	pt := PlanTarget{
+		ID:     m.ID,
+		Title:  m.Title,
+		Mode:   m.Mode,
+		Verify: m.Verify,
+		Reload: m.Reload,
+	}
+	if pt.Mode == "" {
+		pt.Mode = plugin.ModeFile
+	}
+
+	vals := make(map[string]string, len(plug.Spec.Fields))
+	for _, f := range plug.Spec.Fields {
+		v, src := f.Default, theme.SourceField
+		if th != nil {
+			v, src = th.ResolveSource(m.ID, f.Key, f.Default)
+		}
+		vals[f.Key] = v
+		pt.Values = append(pt.Values, PlanValue{Key: f.Key, Value: v, Source: src})
+	}
+
+	if err := validate(Target{Plugin: plug, Values: vals}); err != nil {
+		pt.Error = err.Error()
+		return pt, nil
+	}
+	outs, err := Render(plug, vals)
+	if err != nil {
+		pt.Error = err.Error()
+		return pt, nil
+	}
+	for _, out := range outs {
+		pf := PlanFile{Path: out.Path, Action: ActionModify}
+		live, err := os.ReadFile(out.Path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			pf.Action = ActionCreate
+		case err != nil:
+			return pt, err
+		case bytes.Equal(live, out.Content):
+			pf.Action = ActionUnchanged
+		}
+		pt.Files = append(pt.Files, pf)
+		pt.Warnings = append(pt.Warnings, out.Warnings...)
+	}
+	return pt, nil
+}
@@ -0,0 +1,66 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"palettesmith/internal/plugin"
+	"palettesmith/internal/theme"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPlan(t *testing.T) {
+	t.Run("should_report_values_with_their_source_and_file_actions", func(t *testing.T) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "app.conf")
+		plug := testPlugin(live)
+		plug.Manifest.Reload = []string{"pkill", "-USR2", "app"}
+		th := theme.NewStore(theme.ThemeConfig{ThemeDefaults: map[string]string{"bg": "#111111"}})
+		th.SetOverride("demo", "fg", "#222222")
+
+		plan, err := BuildPlan("nord", []plugin.Plugin{plug}, th)
+
+		require.NoError(t, err)
+		require.Len(t, plan.Targets, 1)
+		pt := plan.Targets[0]
+		assert.Equal(t, plugin.ModeFile, pt.Mode)
+		assert.Equal(t, []PlanValue{
+			{Key: "bg", Value: "#111111", Source: theme.SourceTheme},
+			{Key: "fg", Value: "#222222", Source: theme.SourceOverride},
+		}, pt.Values)
+		assert.Equal(t, []PlanFile{{Path: live, Action: ActionCreate}}, pt.Files)
+		assert.Equal(t, []string{"pkill", "-USR2", "app"}, pt.Reload)
+		assert.True(t, plan.Changed())
+		assert.False(t, plan.Failed())
+		assert.NoFileExists(t, live, "planning must not write")
+	})
+
+	t.Run("should_mark_files_that_already_match", func(t *testing.T) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "app.conf")
+		plug := testPlugin(live)
+		outs, err := Render(plug, Values(plug, nil))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(live, outs[0].Content, 0o644))
+
+		plan, err := BuildPlan("", []plugin.Plugin{plug}, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, ActionUnchanged, plan.Targets[0].Files[0].Action)
+		assert.False(t, plan.Changed())
+	})
+
+	t.Run("should_record_invalid_values_on_the_target", func(t *testing.T) {
+		plug := testPlugin(filepath.Join(t.TempDir(), "app.conf"))
+		th := theme.NewStore(theme.ThemeConfig{ThemeDefaults: map[string]string{"bg": "black"}})
+
+		plan, err := BuildPlan("", []plugin.Plugin{plug}, th)
+
+		require.NoError(t, err)
+		assert.Contains(t, plan.Targets[0].Error, "field 'bg'")
+		assert.True(t, plan.Failed())
+	})
+}
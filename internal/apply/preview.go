@@ -0,0 +1,49 @@
+package apply
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"palettesmith/internal/diff"
+	"palettesmith/internal/plugin"
+)
+
+// FileDiff is the pending change to a single live file
+type FileDiff struct {
+	PluginID string
+	Path     string
+	Exists   bool
+	Unified  string // empty when the file would not change
+}
+
+// Changed reports whether applying would modify the file
+func (d FileDiff) Changed() bool { return d.Unified != "" }
+
+// Preview renders a plugin and diffs each output against its live file
+// without writing anything
+func Preview(plug plugin.Plugin, vals map[string]string) ([]FileDiff, error) {
+	outs, err := Render(plug, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]FileDiff, 0, len(outs))
+	for _, out := range outs {
+		d := FileDiff{PluginID: out.PluginID, Path: out.Path, Exists: true}
+		live, err := os.ReadFile(out.Path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			d.Exists = false
+		case err != nil:
+			return nil, err
+		}
+
+		oldName := out.Path
+		if !d.Exists {
+			oldName = "/dev/null"
+		}
+		d.Unified = diff.Unified(oldName, out.Path, string(live), string(out.Content))
+		diffs = append(diffs, d)
+	}
+	return diffs, nil
+}
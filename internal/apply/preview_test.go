@@ -0,0 +1,41 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview(t *testing.T) {
+	t.Run("should_diff_new_file_against_dev_null", func(t *testing.T) {
+		live := filepath.Join(t.TempDir(), "app.conf")
+
+		diffs, err := Preview(testPlugin(live), map[string]string{"bg": "#000000", "fg": "#ffffff"})
+
+		require.NoError(t, err)
+		require.Len(t, diffs, 1)
+		assert.False(t, diffs[0].Exists)
+		assert.True(t, diffs[0].Changed())
+		assert.Contains(t, diffs[0].Unified, "--- /dev/null\n")
+		assert.Contains(t, diffs[0].Unified, "+bg = #000000\n")
+		assert.NoFileExists(t, live)
+	})
+
+	t.Run("should_report_unchanged_files", func(t *testing.T) {
+		live := filepath.Join(t.TempDir(), "app.conf")
+		vals := map[string]string{"bg": "#000000", "fg": "#ffffff"}
+		outs, err := Render(testPlugin(live), vals)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(live, outs[0].Content, 0o644))
+
+		diffs, err := Preview(testPlugin(live), vals)
+
+		require.NoError(t, err)
+		require.Len(t, diffs, 1)
+		assert.True(t, diffs[0].Exists)
+		assert.False(t, diffs[0].Changed())
+	})
+}
@@ -0,0 +1,346 @@
+package apply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Staging directory layout:
+//
+//	<staging>/next/<live path>                  files rendered by the current apply
+//	<staging>/backup/<generation>/manifest.json what the generation replaced
+//	<staging>/backup/<generation>/files/<path>  previous live contents
+const (
+	nextDirName      = "next"
+	backupDirName    = "backup"
+	backupFilesDir   = "files"
+	backupManifest   = "manifest.json"
+	generationLayout = "20060102T150405.000000000Z"
+)
+
+// Generation is one backup of the live files replaced by an apply
+type Generation struct {
+	ID      string       `json:"id"`
+	Created time.Time    `json:"created"`
+	Plugins []string     `json:"plugins"`
+	Files   []BackupFile `json:"files"`
+
+	Dir string `json:"-"` // absolute dir of the generation (filled at load)
+}
+
+// BackupFile records the state of a live path before an apply touched it
+type BackupFile struct {
+	Path    string      `json:"path"`
+	Existed bool        `json:"existed"`
+	Mode    os.FileMode `json:"mode,omitempty"` // permission bits of the file
+
+	// Target is the file a symlinked Path resolved to, which is what the
+	// apply wrote and what a rollback restores or removes
+	Target string `json:"target,omitempty"`
+}
+
+// live returns the file the apply actually wrote for this entry
+func (f BackupFile) live() string {
+	if f.Target != "" {
+		return f.Target
+	}
+	return f.Path
+}
+
+// defaultFileMode is used for live files that do not exist yet
+const defaultFileMode os.FileMode = 0o644
+
+// maxSymlinkHops bounds symlink resolution so a link loop can't hang an apply
+const maxSymlinkHops = 40
+
+// Commit stages outputs, backs up the live files they replace and promotes
+// them. Either every output is promoted or the live files are left as they were.
+func Commit(stagingDir string, outs []Output) (Generation, error) {
+	staged, err := stage(stagingDir, outs)
+	if err != nil {
+		return Generation{}, fmt.Errorf("failed to stage: %w", err)
+	}
+	return commitStaged(stagingDir, staged, outs)
+}
+
+// commitStaged backs up and promotes outputs already written by stage
+func commitStaged(stagingDir string, staged []string, outs []Output) (Generation, error) {
+	gen, err := backup(stagingDir, outs)
+	if err != nil {
+		_ = os.RemoveAll(gen.Dir)
+		return Generation{}, fmt.Errorf("failed to back up live files: %w", err)
+	}
+
+	if err := promote(staged, outs, gen); err != nil {
+		return Generation{}, err
+	}
+
+	// The staged copies are only needed until promotion succeeds
+	_ = os.RemoveAll(filepath.Join(stagingDir, nextDirName))
+	return gen, nil
+}
+
+// stage writes outputs under <stagingDir>/next, mirroring their live paths
+func stage(stagingDir string, outs []Output) ([]string, error) {
+	next := filepath.Join(stagingDir, nextDirName)
+	if err := os.RemoveAll(next); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(outs))
+	for _, out := range outs {
+		p := mirrorPath(next, out.Path)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return nil, err
+		}
+		if err := writeFileSync(p, out.Content, 0o644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// backup copies the current live files into a new generation
+func backup(stagingDir string, outs []Output) (Generation, error) {
+	now := time.Now().UTC()
+	gen := Generation{ID: now.Format(generationLayout), Created: now}
+	gen.Dir = filepath.Join(stagingDir, backupDirName, gen.ID)
+	if err := os.MkdirAll(filepath.Join(gen.Dir, backupFilesDir), 0o755); err != nil {
+		return gen, err
+	}
+
+	plugins := map[string]bool{}
+	for _, out := range outs {
+		if !plugins[out.PluginID] {
+			plugins[out.PluginID] = true
+			gen.Plugins = append(gen.Plugins, out.PluginID)
+		}
+
+		var target string
+		if resolved, err := resolveLive(out.Path); err != nil {
+			return gen, fmt.Errorf("failed to resolve %s: %w", out.Path, err)
+		} else if resolved != out.Path {
+			target = resolved
+		}
+
+		data, err := os.ReadFile(out.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			gen.Files = append(gen.Files, BackupFile{Path: out.Path, Target: target})
+			continue
+		}
+		if err != nil {
+			return gen, err
+		}
+		info, err := os.Stat(out.Path)
+		if err != nil {
+			return gen, err
+		}
+		p := mirrorPath(filepath.Join(gen.Dir, backupFilesDir), out.Path)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return gen, err
+		}
+		if err := writeFileSync(p, data, 0o644); err != nil {
+			return gen, err
+		}
+		gen.Files = append(gen.Files, BackupFile{Path: out.Path, Existed: true, Mode: info.Mode().Perm(), Target: target})
+	}
+
+	data, err := json.MarshalIndent(gen, "", "  ")
+	if err != nil {
+		return gen, err
+	}
+	if err := writeFileSync(filepath.Join(gen.Dir, backupManifest), data, 0o644); err != nil {
+		return gen, err
+	}
+	return gen, nil
+}
+
+// promote moves staged files into place. Every file is first copied next to
+// its destination so the final rename never crosses filesystems; if any
+// rename fails the already promoted files are restored from the backup.
+// Symlinked live paths are written through to their target and existing
+// permission bits are kept. The generation is discarded whenever the live
+// files end up unchanged.
+func promote(staged []string, outs []Output, gen Generation) error {
+	tmps := make([]string, 0, len(outs))
+	dests := make([]string, 0, len(outs))
+	cleanup := func() {
+		for _, t := range tmps {
+			_ = os.Remove(t)
+		}
+		_ = os.RemoveAll(gen.Dir)
+	}
+
+	for i, out := range outs {
+		data, err := os.ReadFile(staged[i])
+		if err != nil {
+			cleanup()
+			return err
+		}
+		dest, err := resolveLive(out.Path)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("failed to resolve %s: %w", out.Path, err)
+		}
+		tmp, err := writeTemp(dest, data, liveMode(dest, defaultFileMode))
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("failed to write %s: %w", out.Path, err)
+		}
+		tmps = append(tmps, tmp)
+		dests = append(dests, dest)
+	}
+
+	for i, out := range outs {
+		if err := os.Rename(tmps[i], dests[i]); err != nil {
+			for _, t := range tmps[i:] {
+				_ = os.Remove(t)
+			}
+			if rerr := restore(gen, gen.Files[:i]); rerr != nil {
+				// Keep the generation so the user can still roll back by hand
+				return fmt.Errorf("failed to promote %s: %w (restore also failed: %v)", out.Path, err, rerr)
+			}
+			_ = os.RemoveAll(gen.Dir)
+			return fmt.Errorf("failed to promote %s: %w", out.Path, err)
+		}
+		syncDir(filepath.Dir(dests[i]))
+	}
+	return nil
+}
+
+// restore puts the given backup entries of a generation back in place,
+// removing files that did not exist before the apply. Symlinks at the live
+// paths are left alone; only the files they pointed at are touched.
+func restore(gen Generation, files []BackupFile) error {
+	var errs []error
+	for _, f := range files {
+		if !f.Existed {
+			if err := os.Remove(f.live()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		data, err := os.ReadFile(mirrorPath(filepath.Join(gen.Dir, backupFilesDir), f.Path))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		dest, err := resolveLive(f.Path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		perm := f.Mode
+		if perm == 0 {
+			// generations written before modes were recorded
+			perm = liveMode(dest, defaultFileMode)
+		}
+		tmp, err := writeTemp(dest, data, perm)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.Rename(tmp, dest); err != nil {
+			_ = os.Remove(tmp)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveLive follows symlinks at a live path to the file that should be
+// rewritten, so a link into a dotfiles tree keeps pointing there. Dangling
+// links resolve to their (missing) target.
+func resolveLive(path string) (string, error) {
+	for i := 0; i < maxSymlinkHops; i++ {
+		info, err := os.Lstat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return path, nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return "", fmt.Errorf("too many levels of symbolic links")
+}
+
+// liveMode returns the permission bits of an existing file, or fallback
+func liveMode(path string, fallback os.FileMode) os.FileMode {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fallback
+	}
+	return info.Mode().Perm()
+}
+
+// mirrorPath maps an absolute live path under root
+func mirrorPath(root, live string) string {
+	return filepath.Join(root, filepath.Clean(live))
+}
+
+// writeTemp writes data to a temp file next to dest and returns its path
+func writeTemp(dest string, data []byte, perm os.FileMode) (string, error) {
+	dir := filepath.Dir(dest)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(dest)+".palettesmith-*")
+	if err != nil {
+		return "", err
+	}
+	if err := writeAndSync(f, data, perm); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// writeFileSync writes a file and flushes it to disk
+func writeFileSync(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	return writeAndSync(f, data, perm)
+}
+
+func writeAndSync(f *os.File, data []byte, perm os.FileMode) error {
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes a directory entry so a rename survives a crash
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
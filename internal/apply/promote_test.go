@@ -0,0 +1,206 @@
+package apply
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommit(t *testing.T) {
+	t.Run("should_back_up_previous_live_files_and_promote_new_ones", func(t *testing.T) {
+		dir := t.TempDir()
+		staging := filepath.Join(dir, "staging")
+		existing := filepath.Join(dir, "live", "a.conf")
+		fresh := filepath.Join(dir, "live", "b.conf")
+		require.NoError(t, os.MkdirAll(filepath.Dir(existing), 0o755))
+		require.NoError(t, os.WriteFile(existing, []byte("old\n"), 0o644))
+
+		gen, err := Commit(staging, []Output{
+			{PluginID: "demo", Path: existing, Content: []byte("new a\n")},
+			{PluginID: "demo", Path: fresh, Content: []byte("new b\n")},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"demo"}, gen.Plugins)
+
+		data, err := os.ReadFile(existing)
+		require.NoError(t, err)
+		assert.Equal(t, "new a\n", string(data))
+
+		backedUp, err := os.ReadFile(filepath.Join(staging, "backup", gen.ID, "files", existing))
+		require.NoError(t, err)
+		assert.Equal(t, "old\n", string(backedUp))
+
+		raw, err := os.ReadFile(filepath.Join(staging, "backup", gen.ID, "manifest.json"))
+		require.NoError(t, err)
+		var saved Generation
+		require.NoError(t, json.Unmarshal(raw, &saved))
+		assert.Equal(t, []BackupFile{{Path: existing, Existed: true, Mode: 0o644}, {Path: fresh, Existed: false}}, saved.Files)
+
+		assert.NoDirExists(t, filepath.Join(staging, "next"))
+	})
+
+	t.Run("should_leave_live_files_untouched_when_a_destination_is_unwritable", func(t *testing.T) {
+		dir := t.TempDir()
+		staging := filepath.Join(dir, "staging")
+		good := filepath.Join(dir, "live", "a.conf")
+		require.NoError(t, os.MkdirAll(filepath.Dir(good), 0o755))
+		require.NoError(t, os.WriteFile(good, []byte("old\n"), 0o644))
+
+		// A regular file where a parent directory is expected cannot be written through
+		blocker := filepath.Join(dir, "blocker")
+		require.NoError(t, os.WriteFile(blocker, []byte("x"), 0o644))
+
+		_, err := Commit(staging, []Output{
+			{PluginID: "demo", Path: good, Content: []byte("new\n")},
+			{PluginID: "demo", Path: filepath.Join(blocker, "b.conf"), Content: []byte("new\n")},
+		})
+
+		require.Error(t, err)
+		data, err := os.ReadFile(good)
+		require.NoError(t, err)
+		assert.Equal(t, "old\n", string(data))
+
+		entries, err := os.ReadDir(filepath.Dir(good))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temp files must be cleaned up")
+
+		gens, err := os.ReadDir(filepath.Join(staging, "backup"))
+		require.NoError(t, err)
+		assert.Empty(t, gens, "failed applies must not leave a backup generation")
+	})
+}
+
+func TestCommit_Symlinks(t *testing.T) {
+	t.Run("should_write_through_a_symlink_to_its_target", func(t *testing.T) {
+		dir := t.TempDir()
+		dotfile := filepath.Join(dir, "dotfiles", "waybar", "style.css")
+		link := filepath.Join(dir, "config", "waybar", "style.css")
+		require.NoError(t, os.MkdirAll(filepath.Dir(dotfile), 0o755))
+		require.NoError(t, os.MkdirAll(filepath.Dir(link), 0o755))
+		require.NoError(t, os.WriteFile(dotfile, []byte("old\n"), 0o644))
+		require.NoError(t, os.Symlink("../../dotfiles/waybar/style.css", link))
+
+		gen, err := Commit(filepath.Join(dir, "staging"), []Output{{PluginID: "demo", Path: link, Content: []byte("new\n")}})
+
+		require.NoError(t, err)
+		info, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&os.ModeSymlink, "the link must survive the apply")
+		assert.Equal(t, "new\n", readString(t, dotfile))
+
+		require.NoError(t, restore(gen, gen.Files))
+		info, err = os.Lstat(link)
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&os.ModeSymlink, "the link must survive a rollback")
+		assert.Equal(t, "old\n", readString(t, dotfile))
+	})
+
+	t.Run("should_create_the_target_of_a_dangling_symlink", func(t *testing.T) {
+		dir := t.TempDir()
+		dotfile := filepath.Join(dir, "dotfiles", "app.conf")
+		link := filepath.Join(dir, "app.conf")
+		require.NoError(t, os.Symlink(dotfile, link))
+
+		_, err := Commit(filepath.Join(dir, "staging"), []Output{{PluginID: "demo", Path: link, Content: []byte("new\n")}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "new\n", readString(t, dotfile))
+	})
+
+	t.Run("should_keep_a_dangling_symlink_when_rolling_back", func(t *testing.T) {
+		dir := t.TempDir()
+		dotfile := filepath.Join(dir, "dotfiles", "app.conf")
+		link := filepath.Join(dir, "app.conf")
+		require.NoError(t, os.Symlink(dotfile, link))
+		gen, err := Commit(filepath.Join(dir, "staging"), []Output{{PluginID: "demo", Path: link, Content: []byte("new\n")}})
+		require.NoError(t, err)
+
+		err = restore(gen, gen.Files)
+
+		require.NoError(t, err)
+		info, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&os.ModeSymlink)
+		assert.NoFileExists(t, dotfile)
+	})
+}
+
+func TestCommit_Modes(t *testing.T) {
+	t.Run("should_preserve_the_permission_bits_of_live_files", func(t *testing.T) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "app.conf")
+		require.NoError(t, os.WriteFile(live, []byte("old\n"), 0o600))
+
+		_, err := Commit(filepath.Join(dir, "staging"), []Output{{PluginID: "demo", Path: live, Content: []byte("new\n")}})
+
+		require.NoError(t, err)
+		info, err := os.Stat(live)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("should_keep_read_only_files_read_only", func(t *testing.T) {
+		dir := t.TempDir()
+		live := filepath.Join(dir, "app.conf")
+		require.NoError(t, os.WriteFile(live, []byte("old\n"), 0o444))
+
+		gen, err := Commit(filepath.Join(dir, "staging"), []Output{{PluginID: "demo", Path: live, Content: []byte("new\n")}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "new\n", readString(t, live))
+		info, err := os.Stat(live)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o444), info.Mode().Perm())
+
+		require.NoError(t, restore(gen, gen.Files))
+		info, err = os.Stat(live)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o444), info.Mode().Perm())
+	})
+
+	t.Run("should_fail_cleanly_in_a_read_only_directory", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("root ignores directory permissions")
+		}
+		dir := t.TempDir()
+		ro := filepath.Join(dir, "ro")
+		live := filepath.Join(ro, "app.conf")
+		require.NoError(t, os.MkdirAll(ro, 0o755))
+		require.NoError(t, os.WriteFile(live, []byte("old\n"), 0o644))
+		require.NoError(t, os.Chmod(ro, 0o555))
+		t.Cleanup(func() { _ = os.Chmod(ro, 0o755) })
+
+		_, err := Commit(filepath.Join(dir, "staging"), []Output{{PluginID: "demo", Path: live, Content: []byte("new\n")}})
+
+		require.Error(t, err)
+		assert.Equal(t, "old\n", readString(t, live))
+	})
+}
+
+func TestRestore(t *testing.T) {
+	t.Run("should_restore_existing_files_and_remove_created_ones", func(t *testing.T) {
+		dir := t.TempDir()
+		staging := filepath.Join(dir, "staging")
+		existing := filepath.Join(dir, "a.conf")
+		created := filepath.Join(dir, "b.conf")
+		require.NoError(t, os.WriteFile(existing, []byte("old\n"), 0o644))
+
+		gen, err := Commit(staging, []Output{
+			{PluginID: "demo", Path: existing, Content: []byte("new\n")},
+			{PluginID: "demo", Path: created, Content: []byte("new\n")},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, restore(gen, gen.Files))
+
+		data, err := os.ReadFile(existing)
+		require.NoError(t, err)
+		assert.Equal(t, "old\n", string(data))
+		assert.NoFileExists(t, created)
+	})
+}
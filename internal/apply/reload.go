@@ -0,0 +1,141 @@
+package apply
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"palettesmith/internal/plugin"
+	"strings"
+	"time"
+)
+
+// DefaultReloadTimeout bounds how long a plugin reload command may run
+const DefaultReloadTimeout = 10 * time.Second
+
+// ReloadResult captures the outcome of running a plugin's reload command
+type ReloadResult struct {
+	PluginID string
+	Command  []string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+	Err      error // nil on a zero exit
+}
+
+// Failed reports whether the reload command did not complete successfully
+func (r ReloadResult) Failed() bool { return r.Err != nil }
+
+// Summary is a one-line description suitable for a status line
+func (r ReloadResult) Summary() string {
+	if !r.Failed() {
+		return fmt.Sprintf("reload %s ok", r.PluginID)
+	}
+	// A cycle is a configuration problem, so say so instead of echoing output
+	detail := ""
+	if !errors.Is(r.Err, ErrReloadCycle) {
+		detail = firstLine(r.Stderr)
+		if detail == "" {
+			detail = firstLine(r.Stdout)
+		}
+	}
+	if detail == "" {
+		detail = firstLine(r.Err.Error())
+	}
+	if r.ExitCode > 0 {
+		return fmt.Sprintf("reload %s failed (exit %d): %s", r.PluginID, r.ExitCode, detail)
+	}
+	return fmt.Sprintf("reload %s failed: %s", r.PluginID, detail)
+}
+
+// Reload runs a plugin's reload command with a timeout. Plugins without a
+// reload command report ok and false.
+func Reload(ctx context.Context, m plugin.Manifest, timeout time.Duration) (ReloadResult, bool) {
+	res := ReloadResult{PluginID: m.ID, Command: m.Reload}
+	if len(m.Reload) == 0 {
+		return res, false
+	}
+	if timeout <= 0 {
+		timeout = DefaultReloadTimeout
+	}
+	run := runCommand(ctx, m.Reload, nil, timeout)
+	res.Stdout, res.Stderr = run.stdout, run.stderr
+	res.ExitCode, res.Duration, res.Err = run.exitCode, run.duration, run.err
+	return res, true
+}
+
+// commandRun is the captured outcome of an external command
+type commandRun struct {
+	stdout, stderr string
+	exitCode       int // -1 when the command did not exit normally
+	duration       time.Duration
+	err            error // nil on a zero exit
+}
+
+// runCommand runs argv with a timeout and extra environment, capturing its output
+func runCommand(ctx context.Context, argv, env []string, timeout time.Duration) commandRun {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	run := commandRun{stdout: stdout.String(), stderr: stderr.String(), duration: time.Since(start)}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		run.exitCode = -1
+		run.err = fmt.Errorf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		run.exitCode = exitErr.ExitCode()
+		run.err = err
+	default:
+		run.exitCode = -1
+		run.err = err
+	}
+	return run
+}
+
+// ReloadPlugins reloads every listed plugin known to the store (see
+// ReloadAll) and returns the results of the plugins that declare a reload
+// command
+func ReloadPlugins(ctx context.Context, st *plugin.Store, ids []string) []ReloadResult {
+	manifests := make([]plugin.Manifest, 0, len(ids))
+	for _, id := range ids {
+		if plug, ok := st.Get(id); ok {
+			manifests = append(manifests, plug.Manifest)
+		}
+	}
+	return ReloadAll(ctx, manifests, DefaultReloadWorkers)
+}
+
+// ReloadError joins the failures among reload results, or returns nil
+func ReloadError(results []ReloadResult) error {
+	var errs []error
+	for _, r := range results {
+		if r.Failed() {
+			errs = append(errs, errors.New(r.Summary()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package apply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"palettesmith/internal/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	t.Run("should_report_not_run_without_reload_command", func(t *testing.T) {
+		_, ran := Reload(context.Background(), plugin.Manifest{ID: "demo"}, time.Second)
+
+		assert.False(t, ran)
+	})
+
+	t.Run("should_capture_output_of_successful_command", func(t *testing.T) {
+		m := plugin.Manifest{ID: "demo", Reload: []string{"sh", "-c", "echo done; echo warn >&2"}}
+
+		res, ran := Reload(context.Background(), m, time.Second)
+
+		require.True(t, ran)
+		assert.False(t, res.Failed())
+		assert.Equal(t, "done\n", res.Stdout)
+		assert.Equal(t, "warn\n", res.Stderr)
+		assert.Equal(t, 0, res.ExitCode)
+	})
+
+	t.Run("should_report_exit_code_and_stderr_on_failure", func(t *testing.T) {
+		m := plugin.Manifest{ID: "demo", Reload: []string{"sh", "-c", "echo 'bad config' >&2; exit 3"}}
+
+		res, _ := Reload(context.Background(), m, time.Second)
+
+		assert.True(t, res.Failed())
+		assert.Equal(t, 3, res.ExitCode)
+		assert.Equal(t, "reload demo failed (exit 3): bad config", res.Summary())
+	})
+
+	t.Run("should_time_out_hanging_commands", func(t *testing.T) {
+		m := plugin.Manifest{ID: "demo", Reload: []string{"sleep", "5"}}
+
+		res, _ := Reload(context.Background(), m, 50*time.Millisecond)
+
+		assert.True(t, res.Failed())
+		assert.Contains(t, res.Err.Error(), "timed out")
+		assert.Less(t, res.Duration, 5*time.Second)
+	})
+
+	t.Run("should_fail_when_command_is_missing", func(t *testing.T) {
+		m := plugin.Manifest{ID: "demo", Reload: []string{"palettesmith-no-such-binary"}}
+
+		res, _ := Reload(context.Background(), m, time.Second)
+
+		assert.True(t, res.Failed())
+		assert.Equal(t, -1, res.ExitCode)
+	})
+}
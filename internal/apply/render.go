@@ -0,0 +1,121 @@
+package apply
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"palettesmith/internal/paths"
+	"palettesmith/internal/plugin"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are available to every plugin template
+var templateFuncs = template.FuncMap{
+	"trimHash": func(s string) string { return strings.TrimPrefix(s, "#") },
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+}
+
+// Render produces the outputs for a plugin from the resolved values.
+// In file mode, plugins with templates get one output per template and
+// the rest have every field written as "key = value" to the first user
+// path. Patch mode rewrites matching lines of the first existing user path,
+// block mode maintains a marked block with the same content and include
+// mode writes the templates and references them from the user's config.
+func Render(plug plugin.Plugin, vals map[string]string) ([]Output, error) {
+	switch plug.Manifest.Mode {
+	case "", plugin.ModeFile:
+		if len(plug.Manifest.Templates) > 0 {
+			return renderTemplates(plug, vals)
+		}
+		return renderDefault(plug, vals)
+	case plugin.ModePatch:
+		return renderPatch(plug, vals)
+	case plugin.ModeBlock:
+		return renderBlock(plug, vals)
+	case plugin.ModeInclude:
+		return renderInclude(plug, vals)
+	default:
+		return nil, fmt.Errorf("unknown mode '%s'", plug.Manifest.Mode)
+	}
+}
+
+func renderDefault(plug plugin.Plugin, vals map[string]string) ([]Output, error) {
+	if len(plug.Manifest.UserPaths) == 0 {
+		return nil, fmt.Errorf("plugin '%s' declares no user paths", plug.Manifest.ID)
+	}
+	dest, _, err := paths.Destination(plug.Manifest.UserPaths)
+	if err != nil {
+		return nil, err
+	}
+	content := fmt.Sprintf("# Generated by palettesmith for %s. Do not edit.\n", plug.Manifest.ID) +
+		keyValueLines(plug, vals)
+	return []Output{{PluginID: plug.Manifest.ID, Path: dest, Content: []byte(content)}}, nil
+}
+
+// keyValueLines writes every field as a "key = value" line
+func keyValueLines(plug plugin.Plugin, vals map[string]string) string {
+	var b strings.Builder
+	for _, f := range plug.Spec.Fields {
+		fmt.Fprintf(&b, "%s = %s\n", f.Key, vals[f.Key])
+	}
+	return b.String()
+}
+
+func renderTemplates(plug plugin.Plugin, vals map[string]string) ([]Output, error) {
+	srcs := templateSources(plug.Manifest)
+	outs := make([]Output, 0, len(srcs))
+	for _, src := range srcs {
+		dest, err := paths.WriteTarget(plug.Manifest.Templates[src])
+		if err != nil {
+			return nil, err
+		}
+		content, err := renderTemplate(plug.Manifest, src, vals)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, Output{PluginID: plug.Manifest.ID, Path: dest, Content: content})
+	}
+	return outs, nil
+}
+
+// templateSources lists a manifest's template files in a stable order
+func templateSources(m plugin.Manifest) []string {
+	srcs := make([]string, 0, len(m.Templates))
+	for src := range m.Templates {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+	return srcs
+}
+
+// renderTemplate executes one of a plugin's template files against the palette
+func renderTemplate(m plugin.Manifest, src string, vals map[string]string) ([]byte, error) {
+	path := filepath.Join(m.Dir, filepath.FromSlash(src))
+	var data []byte
+	var err error
+	if m.FS != nil {
+		data, err = fs.ReadFile(m.FS, src)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).
+		Funcs(templateFuncs).
+		Option("missingkey=error").
+		Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vals); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", path, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,117 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"palettesmith/internal/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplate(t *testing.T, dir, name, body string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644))
+}
+
+func TestRender(t *testing.T) {
+	t.Run("should_render_each_template_to_its_output_path", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "templates/a.tmpl", "bg={{ .bg }}\n")
+		writeTemplate(t, dir, "templates/b.tmpl", "rgb({{ trimHash .fg }})\n")
+		plug := plugin.Plugin{Manifest: plugin.Manifest{
+			ID:  "demo",
+			Dir: dir,
+			Templates: map[string]string{
+				"templates/b.tmpl": "/out/b.conf",
+				"templates/a.tmpl": "/out/a.conf",
+			},
+		}}
+
+		outs, err := Render(plug, map[string]string{"bg": "#101010", "fg": "#efefef"})
+
+		require.NoError(t, err)
+		require.Len(t, outs, 2)
+		assert.Equal(t, "/out/a.conf", outs[0].Path)
+		assert.Equal(t, "bg=#101010\n", string(outs[0].Content))
+		assert.Equal(t, "/out/b.conf", outs[1].Path)
+		assert.Equal(t, "rgb(efefef)\n", string(outs[1].Content))
+	})
+
+	t.Run("should_read_templates_from_the_manifest_fs", func(t *testing.T) {
+		plug := plugin.Plugin{Manifest: plugin.Manifest{
+			ID:        "demo",
+			Dir:       "(built-in)/demo",
+			FS:        fstest.MapFS{"templates/a.tmpl": {Data: []byte("bg={{ .bg }}\n")}},
+			Templates: map[string]string{"templates/a.tmpl": "/out/a.conf"},
+		}}
+
+		outs, err := Render(plug, map[string]string{"bg": "#101010"})
+
+		require.NoError(t, err)
+		require.Len(t, outs, 1)
+		assert.Equal(t, "bg=#101010\n", string(outs[0].Content))
+	})
+
+	t.Run("should_fail_on_unknown_palette_key", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "a.tmpl", "{{ .missing }}")
+		plug := plugin.Plugin{Manifest: plugin.Manifest{
+			ID: "demo", Dir: dir, Templates: map[string]string{"a.tmpl": "/out/a.conf"},
+		}}
+
+		_, err := Render(plug, map[string]string{})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should_fail_when_template_file_missing", func(t *testing.T) {
+		plug := plugin.Plugin{Manifest: plugin.Manifest{
+			ID: "demo", Dir: t.TempDir(), Templates: map[string]string{"nope.tmpl": "/out/a.conf"},
+		}}
+
+		_, err := Render(plug, map[string]string{})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read template")
+	})
+
+	t.Run("should_reject_a_glob_template_output", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "a.tmpl", "x\n")
+		plug := plugin.Plugin{Manifest: plugin.Manifest{
+			ID: "demo", Dir: dir, Templates: map[string]string{"a.tmpl": filepath.Join(dir, "*.conf")},
+		}}
+
+		_, err := Render(plug, map[string]string{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "glob")
+		assert.NoFileExists(t, filepath.Join(dir, "*.conf"))
+	})
+
+	t.Run("should_write_key_value_lines_to_an_existing_later_user_path", func(t *testing.T) {
+		dir := t.TempDir()
+		existing := filepath.Join(dir, "b.conf")
+		require.NoError(t, os.WriteFile(existing, []byte("# Generated by palettesmith for demo. Do not edit.\n"), 0o644))
+		plug := testPlugin(filepath.Join(dir, "conf.d", "*.conf"))
+		plug.Manifest.UserPaths = append(plug.Manifest.UserPaths, existing)
+
+		outs, err := Render(plug, map[string]string{"bg": "#000000", "fg": "#ffffff"})
+
+		require.NoError(t, err)
+		assert.Equal(t, existing, outs[0].Path)
+	})
+
+	t.Run("should_fall_back_to_key_value_lines_without_templates", func(t *testing.T) {
+		outs, err := Render(testPlugin("/out/app.conf"), map[string]string{"bg": "#000000", "fg": "#ffffff"})
+
+		require.NoError(t, err)
+		require.Len(t, outs, 1)
+		assert.Contains(t, string(outs[0].Content), "bg = #000000\n")
+	})
+}
@@ -0,0 +1,134 @@
+package apply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrNoBackups is returned when there is nothing to roll back to
+var ErrNoBackups = errors.New("no backup generations available")
+
+// Generations lists the backup generations in the staging dir, newest first
+func Generations(stagingDir string) ([]Generation, error) {
+	root := filepath.Join(stagingDir, backupDirName)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var gens []Generation
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		data, err := os.ReadFile(filepath.Join(dir, backupManifest))
+		if err != nil {
+			// incomplete generation from an interrupted apply
+			continue
+		}
+		var g Generation
+		if err := json.Unmarshal(data, &g); err != nil {
+			continue
+		}
+		g.Dir = dir
+		gens = append(gens, g)
+	}
+	sort.Slice(gens, func(i, j int) bool { return gens[i].ID > gens[j].ID })
+	return gens, nil
+}
+
+// Rollback restores the live files saved in a generation (the newest when id
+// is empty). The generation and every newer one are consumed, so repeated
+// rollbacks step further back in history.
+func Rollback(stagingDir, id string) (Generation, error) {
+	gens, err := Generations(stagingDir)
+	if err != nil {
+		return Generation{}, fmt.Errorf("failed to list backups: %w", err)
+	}
+	if len(gens) == 0 {
+		return Generation{}, ErrNoBackups
+	}
+
+	idx := 0
+	if id != "" {
+		idx = -1
+		for i, g := range gens {
+			if g.ID == id {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return Generation{}, fmt.Errorf("unknown backup generation '%s'", id)
+		}
+	}
+
+	// Restore newest first so the chosen generation's files win for paths
+	// touched by several applies
+	for _, g := range gens[:idx+1] {
+		if err := restore(g, g.Files); err != nil {
+			return g, fmt.Errorf("failed to restore generation '%s': %w", g.ID, err)
+		}
+	}
+	for _, g := range gens[:idx+1] {
+		_ = os.RemoveAll(g.Dir)
+	}
+
+	// The restored contents are now what palettesmith last wrote, hashed
+	// over the same regions as before
+	var restored []string
+	for _, g := range gens[:idx+1] {
+		for _, f := range g.Files {
+			restored = append(restored, f.Path)
+		}
+	}
+	if err := recordHashes(stagingDir, restored, nil); err != nil {
+		return gens[idx], fmt.Errorf("restored, but failed to record file hashes: %w", err)
+	}
+
+	target := gens[idx]
+	target.Plugins = affectedPlugins(gens[:idx+1])
+	return target, nil
+}
+
+// Prune removes the oldest generations beyond the retention count
+func Prune(stagingDir string, keep int) error {
+	gens, err := Generations(stagingDir)
+	if err != nil {
+		return err
+	}
+	if keep < 0 || len(gens) <= keep {
+		return nil
+	}
+	var errs []error
+	for _, g := range gens[keep:] {
+		if err := os.RemoveAll(g.Dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// affectedPlugins collects the plugin ids touched by a set of generations
+func affectedPlugins(gens []Generation) []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, g := range gens {
+		for _, id := range g.Plugins {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
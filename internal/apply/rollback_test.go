@@ -0,0 +1,100 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commitContent applies a single file and returns the generation it created
+func commitContent(t *testing.T, staging, pluginID, path, content string) Generation {
+	t.Helper()
+	gen, err := Commit(staging, []Output{{PluginID: pluginID, Path: path, Content: []byte(content)}})
+	require.NoError(t, err)
+	return gen
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestRollback(t *testing.T) {
+	t.Run("should_restore_latest_generation_and_consume_it", func(t *testing.T) {
+		dir := t.TempDir()
+		staging := filepath.Join(dir, "staging")
+		live := filepath.Join(dir, "app.conf")
+		require.NoError(t, os.WriteFile(live, []byte("v0"), 0o644))
+		commitContent(t, staging, "demo", live, "v1")
+		commitContent(t, staging, "demo", live, "v2")
+
+		gen, err := Rollback(staging, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"demo"}, gen.Plugins)
+		assert.Equal(t, "v1", readString(t, live))
+
+		_, err = Rollback(staging, "")
+		require.NoError(t, err)
+		assert.Equal(t, "v0", readString(t, live))
+
+		_, err = Rollback(staging, "")
+		assert.ErrorIs(t, err, ErrNoBackups)
+	})
+
+	t.Run("should_restore_a_specific_generation_across_newer_ones", func(t *testing.T) {
+		dir := t.TempDir()
+		staging := filepath.Join(dir, "staging")
+		a := filepath.Join(dir, "a.conf")
+		b := filepath.Join(dir, "b.conf")
+		require.NoError(t, os.WriteFile(a, []byte("a0"), 0o644))
+		first := commitContent(t, staging, "one", a, "a1")
+		commitContent(t, staging, "two", b, "b1")
+		commitContent(t, staging, "one", a, "a2")
+
+		gen, err := Rollback(staging, first.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, "a0", readString(t, a))
+		assert.NoFileExists(t, b)
+		assert.ElementsMatch(t, []string{"one", "two"}, gen.Plugins)
+
+		gens, err := Generations(staging)
+		require.NoError(t, err)
+		assert.Empty(t, gens)
+	})
+
+	t.Run("should_reject_unknown_generation", func(t *testing.T) {
+		dir := t.TempDir()
+		staging := filepath.Join(dir, "staging")
+		commitContent(t, staging, "demo", filepath.Join(dir, "a.conf"), "x")
+
+		_, err := Rollback(staging, "nope")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown backup generation")
+	})
+}
+
+func TestPrune(t *testing.T) {
+	t.Run("should_keep_only_the_newest_generations", func(t *testing.T) {
+		dir := t.TempDir()
+		staging := filepath.Join(dir, "staging")
+		live := filepath.Join(dir, "app.conf")
+		for _, v := range []string{"1", "2", "3", "4"} {
+			commitContent(t, staging, "demo", live, v)
+		}
+
+		require.NoError(t, Prune(staging, 2))
+
+		gens, err := Generations(staging)
+		require.NoError(t, err)
+		require.Len(t, gens, 2)
+		assert.Greater(t, gens[0].ID, gens[1].ID)
+	})
+}
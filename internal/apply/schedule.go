@@ -0,0 +1,201 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"palettesmith/internal/plugin"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultReloadWorkers bounds how many reload commands run at once
+const DefaultReloadWorkers = 4
+
+// ErrReloadCycle is reported by plugins whose After/Before declarations
+// depend on each other
+var ErrReloadCycle = errors.New("reload order has a cycle")
+
+// reloadJob is one distinct reload command shared by one or more plugins
+type reloadJob struct {
+	manifest plugin.Manifest // the first plugin declaring the command
+	deps     map[int]bool    // jobs that must finish first
+	cycle    error           // set when the job's ordering is part of a cycle
+	result   ReloadResult
+}
+
+// ReloadAll runs the reload commands of the given plugins. Identical commands
+// run once, a plugin's command waits for those of the plugins named in its
+// After list (and in other plugins' Before lists) and independent commands
+// run concurrently on up to workers goroutines. Commands ordered in a cycle
+// still run, but their results fail with ErrReloadCycle. One result is returned per
+// plugin with a reload command, in the order the plugins were given.
+func ReloadAll(ctx context.Context, manifests []plugin.Manifest, workers int) []ReloadResult {
+	jobs, jobOf := reloadJobs(manifests)
+	if len(jobs) == 0 {
+		return nil
+	}
+	orderJobs(jobs, manifests, jobOf)
+	if workers <= 0 {
+		workers = DefaultReloadWorkers
+	}
+
+	done := make([]chan struct{}, len(jobs))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(done[i])
+			for d := range jobs[i].deps {
+				<-done[d]
+			}
+			sem <- struct{}{}
+			jobs[i].result, _ = Reload(ctx, jobs[i].manifest, DefaultReloadTimeout)
+			<-sem
+			if jobs[i].cycle != nil {
+				jobs[i].result.Err = errors.Join(jobs[i].cycle, jobs[i].result.Err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var results []ReloadResult
+	for _, m := range manifests {
+		i, ok := jobOf[m.ID]
+		if !ok {
+			continue
+		}
+		res := jobs[i].result
+		res.PluginID = m.ID
+		results = append(results, res)
+	}
+	return results
+}
+
+// reloadJobs groups plugins by reload command, keyed by plugin id
+func reloadJobs(manifests []plugin.Manifest) ([]*reloadJob, map[string]int) {
+	var jobs []*reloadJob
+	byCommand := map[string]int{}
+	jobOf := map[string]int{}
+	for _, m := range manifests {
+		if len(m.Reload) == 0 {
+			continue
+		}
+		if _, dup := jobOf[m.ID]; dup {
+			continue
+		}
+		key := strings.Join(m.Reload, "\x00")
+		i, ok := byCommand[key]
+		if !ok {
+			i = len(jobs)
+			byCommand[key] = i
+			jobs = append(jobs, &reloadJob{manifest: m, deps: map[int]bool{}})
+		}
+		jobOf[m.ID] = i
+	}
+	return jobs, jobOf
+}
+
+// orderJobs turns After/Before declarations into job dependencies. Plugins
+// that are not being reloaded are ignored. Jobs whose declarations form a
+// cycle run one after another in the given order, after everything they
+// depend on outside the cycle, and report ErrReloadCycle.
+func orderJobs(jobs []*reloadJob, manifests []plugin.Manifest, jobOf map[string]int) {
+	edge := func(first, then string) {
+		a, okA := jobOf[strings.ToLower(first)]
+		b, okB := jobOf[strings.ToLower(then)]
+		if okA && okB && a != b {
+			jobs[b].deps[a] = true
+		}
+	}
+	for _, m := range manifests {
+		for _, id := range m.After {
+			edge(id, m.ID)
+		}
+		for _, id := range m.Before {
+			edge(m.ID, id)
+		}
+	}
+
+	for _, cycle := range jobCycles(jobs) {
+		in := make(map[int]bool, len(cycle))
+		ids := make([]string, 0, len(cycle))
+		for _, i := range cycle {
+			in[i] = true
+			ids = append(ids, jobs[i].manifest.ID)
+		}
+		err := fmt.Errorf("%w: %s", ErrReloadCycle, strings.Join(ids, ", "))
+		for n, i := range cycle {
+			for d := range jobs[i].deps {
+				if in[d] {
+					delete(jobs[i].deps, d)
+				}
+			}
+			if n > 0 {
+				jobs[i].deps[cycle[n-1]] = true
+			}
+			jobs[i].cycle = err
+		}
+	}
+}
+
+// jobCycles returns the groups of jobs that depend on each other, each in
+// index order. Jobs merely waiting on a cycle are not part of it.
+func jobCycles(jobs []*reloadJob) [][]int {
+	// Tarjan's strongly connected components over the dependency edges
+	index := make([]int, len(jobs))
+	low := make([]int, len(jobs))
+	onStack := make([]bool, len(jobs))
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	var cycles [][]int
+	next := 0
+
+	var visit func(i int)
+	visit = func(i int) {
+		index[i], low[i] = next, next
+		next++
+		stack = append(stack, i)
+		onStack[i] = true
+		for d := range jobs[i].deps {
+			switch {
+			case index[d] < 0:
+				visit(d)
+				low[i] = min(low[i], low[d])
+			case onStack[d]:
+				low[i] = min(low[i], index[d])
+			}
+		}
+		if low[i] != index[i] {
+			return
+		}
+		var group []int
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			group = append(group, top)
+			if top == i {
+				break
+			}
+		}
+		if len(group) > 1 {
+			sort.Ints(group)
+			cycles = append(cycles, group)
+		}
+	}
+	for i := range jobs {
+		if index[i] < 0 {
+			visit(i)
+		}
+	}
+	return cycles
+}
@@ -0,0 +1,83 @@
+package apply
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"palettesmith/internal/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logReload returns a reload command that appends name to log, after an
+// optional delay so ordering bugs show up
+func logReload(log, name, delay string) []string {
+	return []string{"sh", "-c", "sleep " + delay + "; echo " + name + " >> " + log}
+}
+
+func TestReloadAll(t *testing.T) {
+	t.Run("should_run_plugins_after_the_ones_they_name", func(t *testing.T) {
+		log := filepath.Join(t.TempDir(), "log")
+		manifests := []plugin.Manifest{
+			{ID: "waybar", Reload: logReload(log, "waybar", "0"), After: []string{"Hyprland"}},
+			{ID: "mako", Reload: logReload(log, "mako", "0"), Before: []string{"waybar"}},
+			{ID: "hyprland", Reload: logReload(log, "hyprland", "0.1")},
+		}
+
+		results := ReloadAll(context.Background(), manifests, 4)
+
+		require.Len(t, results, 3)
+		assert.Equal(t, []string{"waybar", "mako", "hyprland"}, []string{results[0].PluginID, results[1].PluginID, results[2].PluginID})
+		lines := strings.Fields(readString(t, log))
+		require.Len(t, lines, 3)
+		assert.Equal(t, "waybar", lines[2])
+	})
+
+	t.Run("should_run_identical_commands_once", func(t *testing.T) {
+		log := filepath.Join(t.TempDir(), "log")
+		cmd := logReload(log, "restart", "0")
+		manifests := []plugin.Manifest{{ID: "a", Reload: cmd}, {ID: "b", Reload: cmd}, {ID: "c"}}
+
+		results := ReloadAll(context.Background(), manifests, 4)
+
+		require.Len(t, results, 2)
+		assert.Equal(t, "a", results[0].PluginID)
+		assert.Equal(t, "b", results[1].PluginID)
+		assert.Equal(t, "restart\n", readString(t, log))
+	})
+
+	t.Run("should_still_run_everything_on_an_ordering_cycle", func(t *testing.T) {
+		log := filepath.Join(t.TempDir(), "log")
+		manifests := []plugin.Manifest{
+			{ID: "a", Reload: logReload(log, "a", "0.05"), After: []string{"b"}},
+			{ID: "b", Reload: logReload(log, "b", "0"), After: []string{"a"}},
+		}
+
+		results := ReloadAll(context.Background(), manifests, 4)
+
+		require.Len(t, results, 2)
+		assert.Equal(t, "a\nb\n", readString(t, log), "cycles fall back to the given order")
+		assert.ErrorIs(t, results[0].Err, ErrReloadCycle)
+		assert.ErrorIs(t, results[1].Err, ErrReloadCycle)
+	})
+
+	t.Run("should_keep_the_order_of_plugins_waiting_on_a_cycle", func(t *testing.T) {
+		log := filepath.Join(t.TempDir(), "log")
+		manifests := []plugin.Manifest{
+			{ID: "waybar", Reload: logReload(log, "waybar", "0"), After: []string{"b"}},
+			{ID: "a", Reload: logReload(log, "a", "0.05"), After: []string{"b"}},
+			{ID: "b", Reload: logReload(log, "b", "0"), After: []string{"a"}},
+		}
+
+		results := ReloadAll(context.Background(), manifests, 4)
+
+		require.Len(t, results, 3)
+		assert.Equal(t, "a\nb\nwaybar\n", readString(t, log))
+		assert.NoError(t, results[0].Err)
+		assert.ErrorIs(t, results[1].Err, ErrReloadCycle)
+		assert.Contains(t, results[1].Summary(), "cycle: a, b")
+	})
+}
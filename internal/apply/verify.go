@@ -0,0 +1,108 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"palettesmith/internal/plugin"
+	"regexp"
+	"strings"
+)
+
+// VerifyResult is the outcome of a plugin's verify command on its staged files
+type VerifyResult struct {
+	PluginID string
+	Command  []string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Err      error // why the staged files were rejected; nil when they passed
+}
+
+// Failed reports whether the staged files were rejected
+func (r VerifyResult) Failed() bool { return r.Err != nil }
+
+// Summary is a one-line description suitable for a status line
+func (r VerifyResult) Summary() string {
+	if !r.Failed() {
+		return fmt.Sprintf("verify %s ok", r.PluginID)
+	}
+	detail := firstLine(r.Stderr)
+	if detail == "" {
+		detail = firstLine(r.Stdout)
+	}
+	if detail == "" {
+		detail = r.Err.Error()
+	}
+	return fmt.Sprintf("verify %s failed: %s", r.PluginID, detail)
+}
+
+// Verify runs a plugin's verify command against the staged copies of its
+// outputs. Plugins without a verify command report ok and false.
+func Verify(ctx context.Context, m plugin.Manifest, staged []string) (VerifyResult, bool) {
+	res := VerifyResult{PluginID: m.ID}
+	if len(m.Verify) == 0 {
+		return res, false
+	}
+
+	var errRe *regexp.Regexp
+	if m.VerifyError != "" {
+		re, err := regexp.Compile(m.VerifyError)
+		if err != nil {
+			res.Err = fmt.Errorf("invalid verify_error pattern: %w", err)
+			return res, true
+		}
+		errRe = re
+	}
+
+	first := ""
+	if len(staged) > 0 {
+		first = staged[0]
+	}
+	res.Command = make([]string, len(m.Verify))
+	for i, arg := range m.Verify {
+		res.Command[i] = strings.ReplaceAll(arg, "{file}", first)
+	}
+	env := []string{"PALETTESMITH_STAGED_FILES=" + strings.Join(staged, string(os.PathListSeparator))}
+
+	run := runCommand(ctx, res.Command, env, DefaultReloadTimeout)
+	res.Stdout, res.Stderr, res.ExitCode, res.Err = run.stdout, run.stderr, run.exitCode, run.err
+	if res.Err == nil && errRe != nil {
+		if loc := errRe.FindString(run.stdout + run.stderr); loc != "" {
+			res.Err = fmt.Errorf("output matches %q: %s", m.VerifyError, loc)
+		}
+	}
+	return res, true
+}
+
+// verifyStaged runs the verify command of every target with staged outputs and
+// drops the outputs of targets that fail. The staged paths stay aligned with
+// the outputs.
+func verifyStaged(manifests map[string]plugin.Manifest, outs []Output, staged []string) ([]Output, []string, map[string]VerifyResult) {
+	byPlugin := map[string][]string{}
+	var order []string
+	for i, out := range outs {
+		if _, ok := byPlugin[out.PluginID]; !ok {
+			order = append(order, out.PluginID)
+		}
+		byPlugin[out.PluginID] = append(byPlugin[out.PluginID], staged[i])
+	}
+
+	results := map[string]VerifyResult{}
+	for _, id := range order {
+		if res, ran := Verify(context.Background(), manifests[id], byPlugin[id]); ran {
+			results[id] = res
+		}
+	}
+
+	keptOuts := make([]Output, 0, len(outs))
+	keptStaged := make([]string, 0, len(staged))
+	for i, out := range outs {
+		if res, ok := results[out.PluginID]; ok && res.Failed() {
+			continue
+		}
+		keptOuts = append(keptOuts, out)
+		keptStaged = append(keptStaged, staged[i])
+	}
+	return keptOuts, keptStaged, results
+}
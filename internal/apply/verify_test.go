@@ -0,0 +1,95 @@
+package apply
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"palettesmith/internal/config"
+	"palettesmith/internal/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	staged := filepath.Join(t.TempDir(), "app.conf")
+	require.NoError(t, os.WriteFile(staged, []byte("bg = #000000\n"), 0o644))
+
+	t.Run("should_report_not_run_without_verify_command", func(t *testing.T) {
+		_, ran := Verify(context.Background(), plugin.Manifest{ID: "demo"}, []string{staged})
+
+		assert.False(t, ran)
+	})
+
+	t.Run("should_pass_the_staged_file_to_the_command", func(t *testing.T) {
+		m := plugin.Manifest{ID: "demo", Verify: []string{"grep", "-q", "bg = #000000", "{file}"}}
+
+		res, ran := Verify(context.Background(), m, []string{staged})
+
+		require.True(t, ran)
+		assert.False(t, res.Failed())
+		assert.Equal(t, staged, res.Command[3])
+	})
+
+	t.Run("should_fail_on_non_zero_exit", func(t *testing.T) {
+		m := plugin.Manifest{ID: "demo", Verify: []string{"sh", "-c", "echo 'unknown key' >&2; exit 1"}}
+
+		res, _ := Verify(context.Background(), m, []string{staged})
+
+		assert.True(t, res.Failed())
+		assert.Equal(t, 1, res.ExitCode)
+		assert.Equal(t, "verify demo failed: unknown key", res.Summary())
+	})
+
+	t.Run("should_fail_when_output_matches_the_error_pattern", func(t *testing.T) {
+		m := plugin.Manifest{ID: "demo", Verify: []string{"sh", "-c", "echo 'config error on line 3'"}, VerifyError: `(?i)error`}
+
+		res, _ := Verify(context.Background(), m, []string{staged})
+
+		assert.True(t, res.Failed())
+		assert.Equal(t, 0, res.ExitCode)
+	})
+}
+
+func TestRunAll_Verify(t *testing.T) {
+	t.Run("should_apply_other_targets_when_one_fails_verification", func(t *testing.T) {
+		dir := t.TempDir()
+		good := testPlugin(filepath.Join(dir, "a.conf"))
+		good.Manifest.Verify = []string{"test", "-s", "{file}"}
+		bad := testPlugin(filepath.Join(dir, "b.conf"))
+		bad.Manifest.ID = "other"
+		bad.Manifest.Verify = []string{"false"}
+		vals := map[string]string{"bg": "#000000", "fg": "#ffffff"}
+
+		batch, err := RunAll(config.Config{StagingDir: filepath.Join(dir, "staging")}, []Target{
+			{Plugin: good, Values: vals},
+			{Plugin: bad, Values: vals},
+		}, Options{})
+
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(dir, "a.conf"))
+		assert.NoFileExists(t, filepath.Join(dir, "b.conf"))
+		require.Len(t, batch.VerifyFailures(), 1)
+		assert.Equal(t, "other", batch.VerifyFailures()[0].PluginID)
+		assert.Empty(t, batch.Results[1].Written)
+		assert.Empty(t, batch.Results[1].Skipped)
+		assert.NoDirExists(t, filepath.Join(dir, "staging", "next"))
+	})
+
+	t.Run("should_promote_nothing_when_every_target_fails", func(t *testing.T) {
+		dir := t.TempDir()
+		plug := testPlugin(filepath.Join(dir, "a.conf"))
+		plug.Manifest.Verify = []string{"false"}
+
+		batch, err := RunAll(config.Config{StagingDir: filepath.Join(dir, "staging")}, []Target{
+			{Plugin: plug, Values: map[string]string{"bg": "#000000", "fg": "#ffffff"}},
+		}, Options{})
+
+		require.NoError(t, err)
+		assert.Empty(t, batch.Backup)
+		assert.NoFileExists(t, filepath.Join(dir, "a.conf"))
+		assert.NoDirExists(t, filepath.Join(dir, "staging", "next"))
+	})
+}
@@ -7,12 +7,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"palettesmith/internal/lock"
 	"path/filepath"
 )
 
 const (
 	PalettesmithConfigDir = ".config/palettesmith"
 	OmarchyConfigDir      = ".config/omarchy"
+
+	// DefaultBackupRetention is how many backup generations are kept when unset
+	DefaultBackupRetention = 10
+
+	// HistoryFile is the apply journal, kept in the palettesmith config dir
+	HistoryFile = "history.jsonl"
+
+	// LockFile serialises applies, rollbacks and config writes across processes
+	LockFile = "palettesmith.lock"
+
+	// OverridesFile keeps the per-target values edited in the form
+	OverridesFile = "overrides.json"
 )
 
 type Config struct {
@@ -20,6 +33,15 @@ type Config struct {
 	CurrentThemeLink string `json:"current_theme_link"`
 	Preset           string `json:"preset"`
 	StagingDir       string `json:"staging_dir"`
+	BackupRetention  int    `json:"backup_retention,omitempty"`
+}
+
+// BackupsToKeep returns the configured backup retention, falling back to the default
+func (c Config) BackupsToKeep() int {
+	if c.BackupRetention > 0 {
+		return c.BackupRetention
+	}
+	return DefaultBackupRetention
 }
 
 type Manager struct {
@@ -89,6 +111,12 @@ func (m *Manager) SaveConfig() error {
 		return fmt.Errorf("failed to save config: cannot determine home directory: %w", err)
 	}
 
+	l, err := lock.Acquire(filepath.Join(configDir, LockFile))
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	defer l.Release()
+
 	configFile := filepath.Join(configDir, "config.json")
 	if err := saveConfigToFile(m.cfg, configFile); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
@@ -134,11 +162,43 @@ func (m *Manager) SetPreset(preset string) error {
 		return fmt.Errorf("failed to set preset '%s': %w", preset, err)
 	}
 
+	// Settings that are not part of a preset carry over
+	newCfg.BackupRetention = m.cfg.BackupRetention
+
 	// Only update the config if validation succeeded
 	m.cfg = newCfg
 	return nil
 }
 
+// HistoryPath returns the location of the apply journal
+func HistoryPath() (string, error) {
+	configDir, err := expandHome(PalettesmithConfigDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, HistoryFile), nil
+}
+
+// OverridesPath returns the location of the saved per-target overrides
+func OverridesPath() (string, error) {
+	configDir, err := expandHome(PalettesmithConfigDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, OverridesFile), nil
+}
+
+// Lock takes the palettesmith lock so that only one process applies, rolls
+// back or saves the config at a time. The error wraps lock.ErrBusy when
+// another process holds it.
+func Lock() (*lock.Lock, error) {
+	configDir, err := expandHome(PalettesmithConfigDir)
+	if err != nil {
+		return nil, err
+	}
+	return lock.Acquire(filepath.Join(configDir, LockFile))
+}
+
 func expandHome(relativePath string) (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -2,10 +2,13 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"palettesmith/internal/lock"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -53,6 +56,16 @@ func TestSetPreset(t *testing.T) {
 		assert.Contains(t, manager.cfg.TargetThemeDir, "omarchy")
 	})
 
+	t.Run("should_keep_backup_retention_when_switching_presets", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		manager := &Manager{cfg: Config{Preset: "generic", BackupRetention: 3}}
+
+		err := manager.SetPreset("omarchy")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, manager.cfg.BackupRetention)
+	})
+
 	t.Run("should_return_error_for_unknown_preset", func(t *testing.T) {
 		manager := &Manager{cfg: Config{Preset: "generic"}}
 
@@ -121,6 +134,16 @@ func TestConfig_JSONSerialization(t *testing.T) {
 	})
 }
 
+func TestConfig_BackupsToKeep(t *testing.T) {
+	t.Run("should_fall_back_to_default_when_unset", func(t *testing.T) {
+		assert.Equal(t, DefaultBackupRetention, Config{}.BackupsToKeep())
+	})
+
+	t.Run("should_use_configured_retention", func(t *testing.T) {
+		assert.Equal(t, 4, Config{BackupRetention: 4}.BackupsToKeep())
+	})
+}
+
 func TestNewManager(t *testing.T) {
 	t.Run("should_create_manager_with_default_config_when_no_config_file_exists", func(t *testing.T) {
 		tempHome := t.TempDir()
@@ -234,6 +257,20 @@ func TestManager_SaveConfig(t *testing.T) {
 		assert.Equal(t, manager.cfg, loadedConfig)
 	})
 
+	t.Run("should_refuse_to_save_while_another_process_holds_the_lock", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		held, err := Lock()
+		require.NoError(t, err)
+		defer held.Release()
+
+		manager := &Manager{cfg: Config{Preset: "test"}}
+		err = manager.SaveConfig()
+
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, lock.ErrBusy))
+		assert.Contains(t, err.Error(), "another apply in progress")
+	})
+
 	t.Run("should_return_error_when_home_directory_unavailable", func(t *testing.T) {
 		originalHome := os.Getenv("HOME")
 		defer os.Setenv("HOME", originalHome)
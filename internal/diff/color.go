@@ -0,0 +1,33 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles for the line kinds of a unified diff
+var (
+	AddStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#8ece6a"))
+	DelStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff6b6b"))
+	HunkStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#7aa2f7"))
+	MetaStyle = lipgloss.NewStyle().Bold(true)
+)
+
+// Colorize styles the lines of a unified diff for a terminal
+func Colorize(unified string) string {
+	lines := strings.Split(strings.TrimSuffix(unified, "\n"), "\n")
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"):
+			lines[i] = MetaStyle.Render(l)
+		case strings.HasPrefix(l, "@@"):
+			lines[i] = HunkStyle.Render(l)
+		case strings.HasPrefix(l, "+"):
+			lines[i] = AddStyle.Render(l)
+		case strings.HasPrefix(l, "-"):
+			lines[i] = DelStyle.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,43 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	t.Run("should_return_empty_for_identical_input", func(t *testing.T) {
+		assert.Equal(t, "", Unified("a", "b", "x\n", "x\n"))
+	})
+
+	t.Run("should_show_a_single_changed_line_with_context", func(t *testing.T) {
+		a := "1\n2\n3\n4\n5\n6\n7\n8\n"
+		b := "1\n2\n3\n4\nfive\n6\n7\n8\n"
+
+		got := Unified("a/f", "b/f", a, b)
+
+		assert.Equal(t, "--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n", got)
+	})
+
+	t.Run("should_split_distant_changes_into_separate_hunks", func(t *testing.T) {
+		a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+		b := "A\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nL\n"
+
+		got := Unified("old", "new", a, b)
+
+		assert.Equal(t, "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n d\n@@ -9,4 +9,4 @@\n i\n j\n k\n-l\n+L\n", got)
+	})
+
+	t.Run("should_describe_a_new_file", func(t *testing.T) {
+		got := Unified("/dev/null", "b/f", "", "x\ny\n")
+
+		assert.Equal(t, "--- /dev/null\n+++ b/f\n@@ -0,0 +1,2 @@\n+x\n+y\n", got)
+	})
+
+	t.Run("should_mark_missing_trailing_newline", func(t *testing.T) {
+		got := Unified("a", "b", "x", "x\n")
+
+		assert.Equal(t, "--- a\n+++ b\n@@ -1 +1 @@\n-x\n\\ No newline at end of file\n+x\n", got)
+	})
+}
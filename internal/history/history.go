@@ -0,0 +1,102 @@
+// Package history keeps an append-only journal of applies so past changes
+// to live files can be traced back to a theme and a time
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is one apply as recorded in the journal
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Theme   string    `json:"theme,omitempty"`
+	Targets []string  `json:"targets"`
+	Backup  string    `json:"backup,omitempty"` // backup generation holding the previous files
+	Files   []File    `json:"files,omitempty"`
+	Reloads []Reload  `json:"reloads,omitempty"`
+}
+
+// File is a live file written by an apply
+type File struct {
+	Target string `json:"target"`
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Reload is the outcome of a target's reload command
+type Reload struct {
+	Target     string   `json:"target"`
+	Command    []string `json:"command"`
+	ExitCode   int      `json:"exit_code,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	DurationMS int64    `json:"duration_ms"`
+}
+
+// Failed reports whether the reload command did not complete successfully
+func (r Reload) Failed() bool { return r.Error != "" }
+
+// Touches reports whether the entry wrote a file for the given target
+func (e Entry) Touches(target string) bool {
+	for _, t := range e.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Append adds an entry as one JSON line at the end of the journal
+func Append(path string, e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	// A single write keeps concurrent appends from interleaving
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the journal entries, oldest first. A missing journal is empty.
+// Lines that fail to parse, such as one torn by a crash mid-write, are skipped.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
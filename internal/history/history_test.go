@@ -0,0 +1,54 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendRead(t *testing.T) {
+	t.Run("should_return_nothing_without_a_journal", func(t *testing.T) {
+		entries, err := Read(filepath.Join(t.TempDir(), "history.jsonl"))
+
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should_read_entries_in_append_order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+		first := Entry{Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Theme: "nord", Targets: []string{"waybar"},
+			Files: []File{{Target: "waybar", Path: "/home/u/.config/waybar/style.css", SHA256: "abc"}}}
+		second := Entry{Time: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC), Theme: "gruvbox", Targets: []string{"hyprland"},
+			Reloads: []Reload{{Target: "hyprland", Command: []string{"hyprctl", "reload"}, Error: "exit status 1", ExitCode: 1}}}
+
+		require.NoError(t, Append(path, first))
+		require.NoError(t, Append(path, second))
+		entries, err := Read(path)
+
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, first, entries[0])
+		assert.Equal(t, second, entries[1])
+		assert.True(t, entries[1].Reloads[0].Failed())
+	})
+
+	t.Run("should_skip_torn_lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "history.jsonl")
+		require.NoError(t, Append(path, Entry{Theme: "nord", Targets: []string{"waybar"}}))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"time":"2024-`)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		entries, err := Read(path)
+
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.True(t, entries[0].Touches("waybar"))
+	})
+}
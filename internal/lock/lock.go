@@ -0,0 +1,70 @@
+// Package lock provides the advisory lock that keeps palettesmith processes
+// from applying or saving configuration at the same time
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrBusy is returned when another process holds the lock
+var ErrBusy = errors.New("another apply in progress")
+
+// Lock is a held advisory lock on a file
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes the lock at path without waiting. When another process holds
+// it the error wraps ErrBusy and names that process if it is known.
+func Acquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := tryLock(f); err != nil {
+		f.Close()
+		if errors.Is(err, ErrBusy) {
+			if pid := holder(path); pid != "" {
+				return nil, fmt.Errorf("%w (pid %s)", ErrBusy, pid)
+			}
+		}
+		return nil, err
+	}
+
+	// The pid is informational only; the lock itself is the flock
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release drops the lock. Releasing a nil lock is a no-op.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	_ = l.f.Truncate(0)
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+// holder reads the pid recorded by the current lock holder
+func holder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,45 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	t.Run("should_refuse_a_second_holder", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "palettesmith.lock")
+		l, err := Acquire(path)
+		require.NoError(t, err)
+		defer l.Release()
+
+		_, err = Acquire(path)
+
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrBusy))
+		assert.Contains(t, err.Error(), "another apply in progress (pid "+strconv.Itoa(os.Getpid())+")")
+	})
+
+	t.Run("should_be_acquirable_again_after_release", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "palettesmith.lock")
+		l, err := Acquire(path)
+		require.NoError(t, err)
+		require.NoError(t, l.Release())
+
+		l, err = Acquire(path)
+
+		require.NoError(t, err)
+		assert.NoError(t, l.Release())
+	})
+
+	t.Run("should_ignore_releasing_a_nil_lock", func(t *testing.T) {
+		var l *Lock
+
+		assert.NoError(t, l.Release())
+	})
+}
@@ -0,0 +1,130 @@
+// Package paths expands and resolves the file paths declared by plugins
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// xdgDefaults are used when an XDG base directory variable is unset or empty,
+// as the XDG Base Directory specification requires
+var xdgDefaults = map[string]string{
+	"XDG_CONFIG_HOME": ".config",
+	"XDG_DATA_HOME":   ".local/share",
+	"XDG_STATE_HOME":  ".local/state",
+	"XDG_CACHE_HOME":  ".cache",
+}
+
+// Expand resolves a leading ~ and $VAR / ${VAR} references. XDG base
+// directories fall back to their defaults; any other unset variable is an
+// error rather than silently expanding to "".
+func Expand(p string) (string, error) {
+	var home string
+	homeDir := func() (string, error) {
+		if home != "" {
+			return home, nil
+		}
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot determine user home directory: %w", err)
+		}
+		home = h
+		return home, nil
+	}
+
+	var expandErr error
+	p = os.Expand(p, func(name string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		if def, ok := xdgDefaults[name]; ok {
+			h, err := homeDir()
+			if err != nil {
+				expandErr = err
+				return ""
+			}
+			return filepath.Join(h, def)
+		}
+		if expandErr == nil {
+			expandErr = fmt.Errorf("environment variable $%s is not set", name)
+		}
+		return ""
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		h, err := homeDir()
+		if err != nil {
+			return "", err
+		}
+		p = filepath.Join(h, strings.TrimPrefix(p, "~"))
+	}
+	return filepath.Clean(p), nil
+}
+
+// Glob expands p and returns the existing paths it matches. Paths without
+// glob metacharacters match themselves when they exist.
+func Glob(p string) ([]string, error) {
+	abs, err := Expand(p)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(abs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+	}
+	return matches, nil
+}
+
+// FirstExisting returns the first existing path among the candidates, in
+// order, expanding globs. ok is false when none exists.
+func FirstExisting(candidates []string) (path string, ok bool, err error) {
+	for _, c := range candidates {
+		matches, err := Glob(c)
+		if err != nil {
+			return "", false, err
+		}
+		for _, m := range matches {
+			if _, err := os.Stat(m); err == nil {
+				return m, true, nil
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return "", false, err
+			}
+		}
+	}
+	return "", false, nil
+}
+
+// IsGlob reports whether p contains glob metacharacters
+func IsGlob(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// WriteTarget expands a path palettesmith is going to create. Glob patterns
+// are rejected: they select among existing files and name no new one.
+func WriteTarget(p string) (string, error) {
+	if IsGlob(p) {
+		return "", fmt.Errorf("cannot create %q: glob patterns only match existing files", p)
+	}
+	return Expand(p)
+}
+
+// Destination picks the file to write among candidates: the first existing
+// one as FirstExisting finds it, or else the first candidate as a new file.
+// existed reports which case applied.
+func Destination(candidates []string) (path string, existed bool, err error) {
+	if p, ok, err := FirstExisting(candidates); err != nil || ok {
+		return p, ok, err
+	}
+	if len(candidates) == 0 {
+		return "", false, errors.New("no candidate paths")
+	}
+	p, err := WriteTarget(candidates[0])
+	return p, false, err
+}
@@ -0,0 +1,127 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	t.Run("should_expand_tilde_to_home", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+
+		p, err := Expand("~/.config/hypr/hyprland.conf")
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(home, ".config/hypr/hyprland.conf"), p)
+	})
+
+	t.Run("should_use_xdg_config_home_when_set", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "/custom/config")
+
+		p, err := Expand("$XDG_CONFIG_HOME/kitty/kitty.conf")
+
+		require.NoError(t, err)
+		assert.Equal(t, "/custom/config/kitty/kitty.conf", p)
+	})
+
+	t.Run("should_default_unset_xdg_variables", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_CONFIG_HOME", "")
+
+		p, err := Expand("${XDG_CONFIG_HOME}/foot/foot.ini")
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(home, ".config/foot/foot.ini"), p)
+	})
+
+	t.Run("should_expand_other_environment_variables", func(t *testing.T) {
+		t.Setenv("DOTFILES", "/srv/dotfiles")
+
+		p, err := Expand("$DOTFILES/waybar/style.css")
+
+		require.NoError(t, err)
+		assert.Equal(t, "/srv/dotfiles/waybar/style.css", p)
+	})
+
+	t.Run("should_reject_unset_variables", func(t *testing.T) {
+		os.Unsetenv("PALETTESMITH_SURELY_UNSET")
+
+		_, err := Expand("$PALETTESMITH_SURELY_UNSET/x.conf")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "PALETTESMITH_SURELY_UNSET")
+	})
+}
+
+func TestFirstExisting(t *testing.T) {
+	t.Run("should_pick_first_existing_candidate_in_order", func(t *testing.T) {
+		dir := t.TempDir()
+		second := filepath.Join(dir, "b.conf")
+		third := filepath.Join(dir, "c.conf")
+		require.NoError(t, os.WriteFile(second, nil, 0o644))
+		require.NoError(t, os.WriteFile(third, nil, 0o644))
+
+		p, ok, err := FirstExisting([]string{filepath.Join(dir, "a.conf"), second, third})
+
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, second, p)
+	})
+
+	t.Run("should_expand_globs", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "conf.d"), 0o755))
+		target := filepath.Join(dir, "conf.d", "10-colors.conf")
+		require.NoError(t, os.WriteFile(target, nil, 0o644))
+
+		p, ok, err := FirstExisting([]string{filepath.Join(dir, "conf.d", "*.conf")})
+
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, target, p)
+	})
+
+	t.Run("should_report_when_nothing_exists", func(t *testing.T) {
+		_, ok, err := FirstExisting([]string{filepath.Join(t.TempDir(), "missing.conf")})
+
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestDestination(t *testing.T) {
+	t.Run("should_prefer_an_existing_later_candidate", func(t *testing.T) {
+		dir := t.TempDir()
+		second := filepath.Join(dir, "b.conf")
+		require.NoError(t, os.WriteFile(second, nil, 0o644))
+
+		p, existed, err := Destination([]string{filepath.Join(dir, "a.conf"), second})
+
+		require.NoError(t, err)
+		assert.True(t, existed)
+		assert.Equal(t, second, p)
+	})
+
+	t.Run("should_create_the_first_candidate_when_none_exists", func(t *testing.T) {
+		dir := t.TempDir()
+
+		p, existed, err := Destination([]string{filepath.Join(dir, "a.conf"), filepath.Join(dir, "b.conf")})
+
+		require.NoError(t, err)
+		assert.False(t, existed)
+		assert.Equal(t, filepath.Join(dir, "a.conf"), p)
+	})
+
+	t.Run("should_refuse_to_create_a_glob", func(t *testing.T) {
+		_, _, err := Destination([]string{filepath.Join(t.TempDir(), "conf.d", "*.conf")})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "glob")
+	})
+}
@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"palettesmith"
+	"path/filepath"
+)
+
+// BuiltinRoot is the root recorded for plugins compiled into the binary
+const BuiltinRoot = "(built-in)"
+
+// builtinFS is the embedded plugins tree, one directory per plugin
+func builtinFS() fs.FS {
+	sub, err := fs.Sub(palettesmith.BuiltinPlugins, "plugins")
+	if err != nil {
+		panic(err) // the embed pattern guarantees the directory
+	}
+	return sub
+}
+
+func builtinRoot() pluginRoot {
+	return pluginRoot{dir: BuiltinRoot, fsys: builtinFS(), builtin: true}
+}
+
+// Builtin loads only the plugins compiled into the binary
+func Builtin() (*Store, error) {
+	return discover([]pluginRoot{builtinRoot()})
+}
+
+// Eject copies a built-in plugin to <root>/<id> so it can be customised; the
+// copy then shadows the built-in. It refuses to overwrite an existing dir.
+func Eject(id, root string) (string, error) {
+	st, err := Builtin()
+	if err != nil {
+		return "", err
+	}
+	p, ok := st.Get(id)
+	if !ok {
+		return "", fmt.Errorf("no built-in plugin '%s'", id)
+	}
+
+	dest := filepath.Join(root, p.Manifest.ID)
+	if _, err := os.Lstat(dest); err == nil {
+		return "", fmt.Errorf("%s already exists", dest)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", err
+	}
+
+	err = fs.WalkDir(p.Manifest.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := fs.ReadFile(p.Manifest.FS, name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		_ = os.RemoveAll(dest)
+		return "", fmt.Errorf("failed to eject '%s': %w", p.Manifest.ID, err)
+	}
+	return dest, nil
+}
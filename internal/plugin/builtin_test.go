@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltin(t *testing.T) {
+	t.Run("should_load_the_embedded_plugins", func(t *testing.T) {
+		st, err := Builtin()
+
+		require.NoError(t, err)
+		assert.Empty(t, st.Errors())
+		p, ok := st.Get("hyprland")
+		require.True(t, ok)
+		assert.Equal(t, BuiltinRoot, p.Root)
+		assert.NotNil(t, p.Manifest.FS)
+		assert.NotEmpty(t, p.Spec.Fields)
+	})
+
+	t.Run("should_be_overridden_by_an_installed_plugin_without_a_warning", func(t *testing.T) {
+		root := t.TempDir()
+		installPlugin(t, root, "hyprland", `{"id": "hyprland", "title": "Mine", "spec": "spec.json", "user_paths": ["~/x"]}`, validSpec)
+
+		st, err := discover(append(dirRoots([]string{root}), builtinRoot()))
+
+		require.NoError(t, err)
+		p, ok := st.Get("hyprland")
+		require.True(t, ok)
+		assert.Equal(t, "Mine", p.Manifest.Title)
+		assert.Nil(t, p.Manifest.FS)
+		assert.Empty(t, st.Warnings())
+	})
+}
+
+func TestEject(t *testing.T) {
+	t.Run("should_copy_the_plugin_so_it_shadows_the_builtin", func(t *testing.T) {
+		root := filepath.Join(t.TempDir(), "plugins")
+
+		dest, err := Eject("Hyprland", root)
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "hyprland"), dest)
+		assert.FileExists(t, filepath.Join(dest, "templates", "palettesmith.conf.tmpl"))
+		assert.Empty(t, ValidateDir(dest))
+
+		st, err := discover(append(dirRoots([]string{root}), builtinRoot()))
+		require.NoError(t, err)
+		p, _ := st.Get("hyprland")
+		assert.Equal(t, root, p.Root)
+	})
+
+	t.Run("should_not_overwrite_an_existing_copy", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "hyprland"), 0o755))
+
+		_, err := Eject("hyprland", root)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+	})
+
+	t.Run("should_reject_unknown_ids", func(t *testing.T) {
+		_, err := Eject("nope", t.TempDir())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no built-in plugin")
+	})
+}
@@ -0,0 +1,37 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var colorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Validate checks a value against the field's type and constraints
+func (f Field) Validate(v string) error {
+	switch f.Type {
+	case "color":
+		if !colorRe.MatchString(v) {
+			return errors.New("expect #RRGGBB")
+		}
+	case "number":
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return errors.New("not a number")
+		}
+		if f.Min != nil && n < *f.Min {
+			return fmt.Errorf("< %.0f", *f.Min)
+		}
+		if f.Max != nil && n > *f.Max {
+			return fmt.Errorf("> %.0f", *f.Max)
+		}
+	case "select":
+		if len(f.Enum) > 0 && !contains(f.Enum, v) {
+			return fmt.Errorf("not one of %s", strings.Join(f.Enum, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestField_Validate(t *testing.T) {
+	lo, hi := 0.0, 12.0
+
+	t.Run("should_accept_hex_colors", func(t *testing.T) {
+		assert.NoError(t, Field{Type: "color"}.Validate("#1e1e2e"))
+	})
+
+	t.Run("should_reject_malformed_colors", func(t *testing.T) {
+		assert.EqualError(t, Field{Type: "color"}.Validate("1e1e2e"), "expect #RRGGBB")
+	})
+
+	t.Run("should_enforce_number_bounds", func(t *testing.T) {
+		f := Field{Type: "number", Min: &lo, Max: &hi}
+
+		assert.NoError(t, f.Validate("2"))
+		assert.EqualError(t, f.Validate("-1"), "< 0")
+		assert.EqualError(t, f.Validate("13"), "> 12")
+		assert.EqualError(t, f.Validate("two"), "not a number")
+	})
+
+	t.Run("should_accept_any_text", func(t *testing.T) {
+		assert.NoError(t, Field{Type: "text"}.Validate("anything"))
+	})
+
+	t.Run("should_restrict_selects_to_their_enum", func(t *testing.T) {
+		f := Field{Type: "select", Enum: []string{"dwindle", "master"}}
+
+		assert.NoError(t, f.Validate("master"))
+		assert.EqualError(t, f.Validate("Master"), "not one of dwindle, master")
+		assert.EqualError(t, f.Validate(""), "not one of dwindle, master")
+	})
+}
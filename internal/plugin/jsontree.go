@@ -0,0 +1,179 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// jsonNode is a parsed JSON value that remembers where it appeared, so
+// diagnostics can point at a line and column
+type jsonNode struct {
+	off   int // byte offset of the first character of the value
+	kind  string
+	str   string      // for "string"
+	num   json.Number // for "number"
+	keys  []jsonKey   // for "object", in document order
+	items []*jsonNode // for "array"
+}
+
+// jsonKey is an object member
+type jsonKey struct {
+	name  string
+	off   int // byte offset of the key
+	value *jsonNode
+}
+
+// JSON kinds as named by JSON Schema
+const (
+	kindObject  = "object"
+	kindArray   = "array"
+	kindString  = "string"
+	kindNumber  = "number"
+	kindBoolean = "boolean"
+	kindNull    = "null"
+)
+
+// get returns the value of the first member named key, or nil
+func (n *jsonNode) get(key string) *jsonNode {
+	if n == nil {
+		return nil
+	}
+	for _, k := range n.keys {
+		if k.name == key {
+			return k.value
+		}
+	}
+	return nil
+}
+
+// errJSONSyntax reports malformed JSON at a byte offset
+type errJSONSyntax struct {
+	off int
+	msg string
+}
+
+func (e *errJSONSyntax) Error() string { return e.msg }
+
+type jsonParser struct {
+	data []byte
+	dec  *json.Decoder
+	pos  int // offset just after the last token read
+}
+
+// parseJSONTree parses data into a tree of positioned nodes
+func parseJSONTree(data []byte) (*jsonNode, error) {
+	p := &jsonParser{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	p.dec.UseNumber()
+	root, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.dec.Token(); err != io.EOF {
+		return nil, &errJSONSyntax{off: p.start(), msg: "unexpected data after the top-level value"}
+	}
+	return root, nil
+}
+
+// start returns the offset of the next token, skipping whitespace and the
+// separators the decoder consumes implicitly
+func (p *jsonParser) start() int {
+	i := p.pos
+	for i < len(p.data) {
+		switch p.data[i] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func (p *jsonParser) token() (json.Token, int, error) {
+	off := p.start()
+	tok, err := p.dec.Token()
+	if err != nil {
+		var se *json.SyntaxError
+		switch {
+		case errors.As(err, &se):
+			// Offset counts the offending byte itself
+			return nil, off, &errJSONSyntax{off: max(0, int(se.Offset)-1), msg: se.Error()}
+		case err == io.EOF:
+			return nil, off, &errJSONSyntax{off: len(p.data), msg: "unexpected end of JSON input"}
+		default:
+			return nil, off, &errJSONSyntax{off: off, msg: err.Error()}
+		}
+	}
+	p.pos = int(p.dec.InputOffset())
+	return tok, off, nil
+}
+
+func (p *jsonParser) value() (*jsonNode, error) {
+	tok, off, err := p.token()
+	if err != nil {
+		return nil, err
+	}
+	n := &jsonNode{off: off}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			n.kind = kindObject
+			for p.dec.More() {
+				ktok, koff, err := p.token()
+				if err != nil {
+					return nil, err
+				}
+				name, ok := ktok.(string)
+				if !ok {
+					return nil, &errJSONSyntax{off: koff, msg: "object key must be a string"}
+				}
+				v, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, jsonKey{name: name, off: koff, value: v})
+			}
+		case '[':
+			n.kind = kindArray
+			for p.dec.More() {
+				v, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				n.items = append(n.items, v)
+			}
+		default:
+			return nil, &errJSONSyntax{off: off, msg: fmt.Sprintf("unexpected %q", rune(t))}
+		}
+		// closing delimiter
+		if _, _, err := p.token(); err != nil {
+			return nil, err
+		}
+	case string:
+		n.kind, n.str = kindString, t
+	case json.Number:
+		n.kind, n.num = kindNumber, t
+	case bool:
+		n.kind = kindBoolean
+	case nil:
+		n.kind = kindNull
+	}
+	return n, nil
+}
+
+// lineCol converts a byte offset into a 1-based line and column
+func lineCol(data []byte, off int) (int, int) {
+	if off > len(data) {
+		off = len(data)
+	}
+	line := 1 + bytes.Count(data[:off], []byte{'\n'})
+	col := off + 1
+	if i := bytes.LastIndexByte(data[:off], '\n'); i >= 0 {
+		col = off - i
+	}
+	return line, col
+}
@@ -4,7 +4,10 @@ package plugin
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -24,8 +27,26 @@ type Field struct {
 	Min     *float64 `json:"min,omitempty"`
 	Max     *float64 `json:"max,omitempty"`
 	Enum    []string `json:"enum,omitempty"`
+
+	// Pattern locates the field's line in patch mode, e.g. "col.active_border = {value}"
+	Pattern string `json:"pattern,omitempty"`
+
+	Color *ColorFormat `json:"color,omitempty"`
+}
+
+// ColorFormat describes how a color field is written; only "hex6" is supported
+type ColorFormat struct {
+	Format string `json:"format,omitempty"`
 }
 
+// Output modes a plugin can use to write its files
+const (
+	ModeFile    = "file"    // render whole files (the default)
+	ModePatch   = "patch"   // rewrite matching lines of an existing file in place
+	ModeBlock   = "block"   // maintain a marked block inside an existing file
+	ModeInclude = "include" // render templates and include them from an existing file
+)
+
 type Manifest struct {
 	ID          string   `json:"id"`
 	Title       string   `json:"title"`
@@ -33,63 +54,180 @@ type Manifest struct {
 	UserPaths   []string `json:"user_paths,omitempty"`
 	SystemPaths []string `json:"system_paths,omitempty"`
 	Reload      []string `json:"reload,omitempty"`
+	After       []string `json:"after,omitempty"`       // plugin ids whose reload must run before this one's
+	Before      []string `json:"before,omitempty"`      // plugin ids whose reload must run after this one's
+	Mode        string   `json:"mode,omitempty"`        // one of the Mode* constants; empty means ModeFile
+	Comment     string   `json:"comment,omitempty"`     // line comment used for block markers (default "#")
+	CommentEnd  string   `json:"comment_end,omitempty"` // closes Comment for block-style comments, e.g. "*/"
+	Include     string   `json:"include,omitempty"`     // include directive, e.g. "source = {path}" or "@import \"{relpath}\";"
+	IncludeAt   string   `json:"include_at,omitempty"`  // where a missing directive is added: "end" (default) or "start"
+
+	// Templates maps template files (relative to the plugin dir) to output paths
+	Templates map[string]string `json:"templates,omitempty"`
+
+	// Verify checks the staged files before they go live; "{file}" expands to
+	// the staged copy of the first output. A non-zero exit, or output matching
+	// VerifyError, keeps the target from being applied.
+	Verify      []string `json:"verify,omitempty"`
+	VerifyError string   `json:"verify_error,omitempty"`
 
 	Dir string `json:"-"` // absolute dir of the plugin (filled at load)
+	FS  fs.FS  `json:"-"` // plugin files when they are not on disk under Dir, e.g. built-ins
 }
 
 type Plugin struct {
 	Manifest Manifest
 	Spec     Spec
+	Root     string // plugin root the plugin was discovered in
 }
 
 type Store struct {
-	byID map[string]Plugin
-	list []Plugin
+	byID     map[string]Plugin
+	list     []Plugin
+	errors   []Problem
+	warnings []Problem
 }
 
+// Problem is a plugin directory that failed to load or was shadowed
+type Problem struct {
+	ID   string // empty when the manifest has no readable id
+	Dir  string
+	Root string
+	Err  error
+}
+
+// Discover loads the plugins of every root returned by Roots, then the
+// plugins built into the binary
 func Discover() (*Store, error) {
-	root := filepath.Join(mustGetwd(), "plugins")
-	entries, err := os.ReadDir(root)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return &Store{byID: map[string]Plugin{}, list: nil}, nil
-		}
-		return nil, err
+	return discover(append(dirRoots(Roots()), builtinRoot()))
+}
+
+// DiscoverIn loads every plugin directory under the given roots. Roots are
+// searched in order and the first plugin seen for an id wins; later ones are
+// reported as warnings, and plugins that fail to load as errors.
+func DiscoverIn(roots []string) (*Store, error) {
+	return discover(dirRoots(roots))
+}
+
+// pluginRoot is a directory of plugin directories, on disk or embedded
+type pluginRoot struct {
+	dir     string // shown to the user; an OS path unless builtin
+	fsys    fs.FS
+	builtin bool
+}
+
+func dirRoots(dirs []string) []pluginRoot {
+	roots := make([]pluginRoot, 0, len(dirs))
+	for _, d := range dirs {
+		roots = append(roots, pluginRoot{dir: d, fsys: os.DirFS(d)})
+	}
+	return roots
+}
+
+func (r pluginRoot) readDir() ([]fs.DirEntry, error) {
+	if r.builtin {
+		return fs.ReadDir(r.fsys, ".")
+	}
+	// os.ReadDir keeps the root's path in the error
+	return os.ReadDir(r.dir)
+}
+
+func (r pluginRoot) join(name string) string {
+	if r.builtin {
+		return path.Join(r.dir, name)
 	}
+	return filepath.Join(r.dir, name)
+}
 
-	seen := map[string]bool{}
+func discover(roots []pluginRoot) (*Store, error) {
+	seen := map[string]string{}
 	var plugs []Plugin
+	st := &Store{}
 
-	for _, e := range entries {
-		if !e.IsDir() {
-			continue
-		}
-		dir := filepath.Join(root, e.Name())
-		mf := filepath.Join(dir, "plugin.json")
-		if _, err := os.Stat(mf); err != nil {
-			continue
-		}
-		p, err := loadOne(mf)
+	for _, root := range roots {
+		entries, err := root.readDir()
 		if err != nil {
-			// ignore bad plugins
+			if !errors.Is(err, fs.ErrNotExist) {
+				st.errors = append(st.errors, Problem{Dir: root.dir, Root: root.dir, Err: err})
+			}
 			continue
 		}
-		if seen[p.Manifest.ID] {
-			continue
+		for _, e := range entries {
+			name := e.Name()
+			dir := root.join(name)
+			// Entries may be symlinks to plugin dirs kept elsewhere
+			if info, err := fs.Stat(root.fsys, name); err != nil || !info.IsDir() {
+				continue
+			}
+			if _, err := fs.Stat(root.fsys, path.Join(name, "plugin.json")); err != nil {
+				continue
+			}
+			sub, err := fs.Sub(root.fsys, name)
+			if err != nil {
+				continue
+			}
+			p, err := loadOne(sub, dir)
+			if err != nil {
+				if !root.builtin {
+					err = loadError(dir, err)
+				}
+				st.errors = append(st.errors, Problem{ID: manifestID(sub), Dir: dir, Root: root.dir, Err: err})
+				continue
+			}
+			if winner, ok := seen[p.Manifest.ID]; ok {
+				// Overriding a built-in plugin is how users customise it
+				if !root.builtin {
+					st.warnings = append(st.warnings, Problem{ID: p.Manifest.ID, Dir: dir, Root: root.dir, Err: fmt.Errorf("shadowed by %s", winner)})
+				}
+				continue
+			}
+			if root.builtin {
+				p.Manifest.FS = sub
+			}
+			p.Root = root.dir
+			seen[p.Manifest.ID] = dir
+			plugs = append(plugs, p)
 		}
-		seen[p.Manifest.ID] = true
-		plugs = append(plugs, p)
 	}
 
-	by := make(map[string]Plugin, len(plugs))
+	st.byID = make(map[string]Plugin, len(plugs))
 	for _, p := range plugs {
-		by[p.Manifest.ID] = p
+		st.byID[p.Manifest.ID] = p
+	}
+	st.list = plugs
+	return st, nil
+}
+
+// manifestID reads just the id of a manifest that failed to load
+func manifestID(fsys fs.FS) string {
+	b, err := fs.ReadFile(fsys, "plugin.json")
+	if err != nil {
+		return ""
+	}
+	var m struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(b, &m) != nil {
+		return ""
 	}
-	return &Store{byID: by, list: plugs}, nil
+	return strings.ToLower(m.ID)
 }
 
-func loadOne(manifestPath string) (Plugin, error) {
-	b, err := os.ReadFile(manifestPath)
+// loadError explains why a plugin failed to load, preferring the first
+// positioned diagnostic from the validator over a bare JSON error
+func loadError(dir string, err error) error {
+	for _, d := range ValidateDir(dir) {
+		if d.Severity == SeverityError {
+			return errors.New(d.String())
+		}
+	}
+	return err
+}
+
+// loadOne reads the manifest and spec of the plugin rooted at fsys; dir is
+// recorded as the manifest's Dir
+func loadOne(fsys fs.FS, dir string) (Plugin, error) {
+	b, err := fs.ReadFile(fsys, "plugin.json")
 	if err != nil {
 		return Plugin{}, err
 	}
@@ -97,12 +235,11 @@ func loadOne(manifestPath string) (Plugin, error) {
 	if err := json.Unmarshal(b, &m); err != nil {
 		return Plugin{}, err
 	}
-	m.Dir = filepath.Dir(manifestPath)
+	m.Dir = dir
 	if m.ID == "" || m.SpecRelPath == "" {
 		return Plugin{}, errors.New("invalid plugin manifest (missing id/spec)")
 	}
-	specPath := filepath.Join(m.Dir, filepath.FromSlash(m.SpecRelPath))
-	sb, err := os.ReadFile(specPath)
+	sb, err := fs.ReadFile(fsys, path.Clean(filepath.ToSlash(m.SpecRelPath)))
 	if err != nil {
 		return Plugin{}, err
 	}
@@ -115,16 +252,10 @@ func loadOne(manifestPath string) (Plugin, error) {
 	if s.ID == "" {
 		s.ID = m.ID
 	}
-	return Plugin{m, s}, nil
+	return Plugin{Manifest: m, Spec: s}, nil
 }
 
 func (st *Store) List() []Plugin               { return st.list }
+func (st *Store) Errors() []Problem            { return st.errors }
+func (st *Store) Warnings() []Problem          { return st.warnings }
 func (st *Store) Get(id string) (Plugin, bool) { p, ok := st.byID[strings.ToLower(id)]; return p, ok }
-
-func mustGetwd() string {
-	wd, err := os.Getwd()
-	if err != nil {
-		return "."
-	}
-	return wd
-}
@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installPlugin writes a plugin under <root>/<name>
+func installPlugin(t *testing.T, root, name, manifest, spec string) string {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plugin.json"), []byte(manifest), 0o644))
+	if spec != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "spec.json"), []byte(spec), 0o644))
+	}
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	t.Run("should_report_broken_and_shadowed_plugins", func(t *testing.T) {
+		root := t.TempDir()
+		installPlugin(t, root, "a-demo", `{"id": "demo", "spec": "spec.json", "user_paths": ["~/x"]}`, validSpec)
+		shadowed := installPlugin(t, root, "b-demo", `{"id": "Demo", "spec": "spec.json", "user_paths": ["~/y"]}`, validSpec)
+		broken := installPlugin(t, root, "broken", `{"id": "broken", "spec": "spec.json",}`, validSpec)
+
+		st, err := DiscoverIn([]string{root})
+
+		require.NoError(t, err)
+		require.Len(t, st.List(), 1)
+		assert.Equal(t, filepath.Join(root, "a-demo"), st.List()[0].Manifest.Dir)
+
+		require.Len(t, st.Warnings(), 1)
+		assert.Equal(t, "demo", st.Warnings()[0].ID)
+		assert.Equal(t, shadowed, st.Warnings()[0].Dir)
+		assert.Contains(t, st.Warnings()[0].Err.Error(), "shadowed by "+filepath.Join(root, "a-demo"))
+
+		require.Len(t, st.Errors(), 1)
+		assert.Equal(t, broken, st.Errors()[0].Dir)
+		assert.Contains(t, st.Errors()[0].Err.Error(), "plugin.json:1:37: error: invalid JSON")
+	})
+
+	t.Run("should_let_earlier_roots_shadow_later_ones", func(t *testing.T) {
+		user, system := t.TempDir(), t.TempDir()
+		installPlugin(t, user, "waybar", `{"id": "waybar", "title": "Mine", "spec": "spec.json", "user_paths": ["~/x"]}`, validSpec)
+		installPlugin(t, system, "waybar", `{"id": "waybar", "title": "Distro", "spec": "spec.json", "user_paths": ["~/x"]}`, validSpec)
+		installPlugin(t, system, "mako", `{"id": "mako", "spec": "spec.json", "user_paths": ["~/y"]}`, validSpec)
+
+		st, err := DiscoverIn([]string{user, filepath.Join(t.TempDir(), "missing"), system})
+
+		require.NoError(t, err)
+		require.Len(t, st.List(), 2)
+		p, ok := st.Get("waybar")
+		require.True(t, ok)
+		assert.Equal(t, "Mine", p.Manifest.Title)
+		assert.Equal(t, user, p.Root)
+		p, _ = st.Get("mako")
+		assert.Equal(t, system, p.Root)
+
+		require.Len(t, st.Warnings(), 1)
+		assert.Equal(t, system, st.Warnings()[0].Root)
+		assert.Empty(t, st.Errors(), "missing roots are not errors")
+	})
+}
+
+func TestRoots(t *testing.T) {
+	t.Run("should_search_plugin_path_then_xdg_config_then_xdg_data", func(t *testing.T) {
+		t.Setenv("HOME", "/home/u")
+		t.Setenv(PluginPathEnv, "/opt/a:/opt/b")
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("XDG_DATA_DIRS", "/usr/local/share:/usr/share")
+
+		assert.Equal(t, []string{
+			"/opt/a",
+			"/opt/b",
+			"/home/u/.config/palettesmith/plugins",
+			"/usr/local/share/palettesmith/plugins",
+			"/usr/share/palettesmith/plugins",
+		}, Roots())
+	})
+
+	t.Run("should_honour_xdg_overrides_and_drop_duplicates", func(t *testing.T) {
+		t.Setenv(PluginPathEnv, "/cfg/palettesmith/plugins")
+		t.Setenv("XDG_CONFIG_HOME", "/cfg")
+		t.Setenv("XDG_DATA_DIRS", "/data")
+
+		assert.Equal(t, []string{"/cfg/palettesmith/plugins", "/data/palettesmith/plugins"}, Roots())
+	})
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"os"
+	"palettesmith/internal/paths"
+	"path/filepath"
+	"strings"
+)
+
+// PluginPathEnv lists extra plugin roots, searched before the XDG ones
+const PluginPathEnv = "PALETTESMITH_PLUGIN_PATH"
+
+// defaultDataDirs is used when $XDG_DATA_DIRS is unset, per the XDG spec
+const defaultDataDirs = "/usr/local/share:/usr/share"
+
+// Roots returns the on-disk plugin search path in precedence order: the
+// entries of $PALETTESMITH_PLUGIN_PATH, then UserRoot, then
+// palettesmith/plugins under each of $XDG_DATA_DIRS. A plugin in an earlier
+// root shadows one with the same id in a later root; the built-in plugins
+// come after all of them.
+func Roots() []string {
+	var roots []string
+	seen := map[string]bool{}
+	add := func(p string) {
+		if p == "" {
+			return
+		}
+		abs, err := paths.Expand(p)
+		if err != nil {
+			return
+		}
+		if a, err := filepath.Abs(abs); err == nil {
+			abs = a
+		}
+		if !seen[abs] {
+			seen[abs] = true
+			roots = append(roots, abs)
+		}
+	}
+
+	for _, p := range filepath.SplitList(os.Getenv(PluginPathEnv)) {
+		add(p)
+	}
+	add(UserRoot())
+
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if strings.TrimSpace(dataDirs) == "" {
+		dataDirs = defaultDataDirs
+	}
+	for _, d := range filepath.SplitList(dataDirs) {
+		if d != "" {
+			add(filepath.Join(d, "palettesmith", "plugins"))
+		}
+	}
+	return roots
+}
+
+// UserRoot is the per-user plugin root, $XDG_CONFIG_HOME/palettesmith/plugins
+func UserRoot() string {
+	root, err := paths.Expand(filepath.Join("$XDG_CONFIG_HOME", "palettesmith", "plugins"))
+	if err != nil {
+		return ""
+	}
+	return root
+}
@@ -0,0 +1,164 @@
+package plugin
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schemas holds the published JSON Schemas for plugin.json and spec.json
+//
+//go:embed schema/*.schema.json
+var Schemas embed.FS
+
+// Schema file names within Schemas
+const (
+	ManifestSchema = "schema/plugin.schema.json"
+	SpecSchema     = "schema/spec.schema.json"
+)
+
+// schema is the subset of JSON Schema the published schemas use
+type schema struct {
+	Type                 string             `json:"type"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []string           `json:"enum"`
+	MinItems             int                `json:"minItems"`
+	MinLength            int                `json:"minLength"`
+	Pattern              string             `json:"pattern"`
+}
+
+// loadSchema reads one of the embedded schemas
+func loadSchema(name string) (*schema, error) {
+	data, err := Schemas.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", name, err)
+	}
+	return &s, nil
+}
+
+// additional returns the schema for undeclared object members and whether
+// they are allowed at all
+func (s *schema) additional() (*schema, bool) {
+	raw := strings.TrimSpace(string(s.AdditionalProperties))
+	switch raw {
+	case "", "true":
+		return nil, true
+	case "false":
+		return nil, false
+	}
+	var sub schema
+	if err := json.Unmarshal(s.AdditionalProperties, &sub); err != nil {
+		return nil, true
+	}
+	return &sub, true
+}
+
+// check validates n against the schema, reporting violations at the
+// offending node. path names n for messages, e.g. "fields[2].type".
+func (s *schema) check(n *jsonNode, path string, report func(off int, msg string)) {
+	label := func(msg string) string {
+		if path == "" {
+			return msg
+		}
+		return path + ": " + msg
+	}
+
+	if s.Type != "" && s.Type != n.kind {
+		report(n.off, label(fmt.Sprintf("expected %s, got %s", s.Type, n.kind)))
+		return
+	}
+
+	switch n.kind {
+	case kindObject:
+		seen := map[string]bool{}
+		for _, k := range n.keys {
+			if seen[k.name] {
+				report(k.off, label(fmt.Sprintf("duplicate key '%s'", k.name)))
+				continue
+			}
+			seen[k.name] = true
+
+			sub, ok := s.Properties[k.name]
+			if !ok {
+				var allowed bool
+				sub, allowed = s.additional()
+				if !allowed {
+					report(k.off, label(fmt.Sprintf("unknown key '%s'%s", k.name, suggestKey(k.name, s.Properties))))
+					continue
+				}
+			}
+			if sub != nil {
+				sub.check(k.value, joinPath(path, k.name), report)
+			}
+		}
+		for _, req := range s.Required {
+			if !seen[req] {
+				report(n.off, label(fmt.Sprintf("missing required key '%s'", req)))
+			}
+		}
+	case kindArray:
+		if len(n.items) < s.MinItems {
+			report(n.off, label(fmt.Sprintf("must have at least %d item(s)", s.MinItems)))
+		}
+		if s.Items != nil {
+			for i, item := range n.items {
+				s.Items.check(item, fmt.Sprintf("%s[%d]", path, i), report)
+			}
+		}
+	case kindString:
+		if utf8.RuneCountInString(n.str) < s.MinLength {
+			report(n.off, label("must not be empty"))
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, n.str) {
+			report(n.off, label(fmt.Sprintf("'%s' is not one of %s", n.str, strings.Join(s.Enum, ", "))))
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(n.str) {
+				report(n.off, label(fmt.Sprintf("'%s' does not match %s", n.str, s.Pattern)))
+			}
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestKey points at a declared key that differs from name only in case
+// or by a plural "s", the most common manifest typos
+func suggestKey(name string, props map[string]*schema) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.EqualFold(k, name) || k+"s" == name || k == name+"s" {
+			return fmt.Sprintf(" (did you mean '%s'?)", k)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,273 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a problem found in a plugin file
+type Diagnostic struct {
+	File     string
+	Line     int // 1-based; 0 when the problem is not tied to a position
+	Column   int
+	Severity string
+	Message  string
+}
+
+// String formats the diagnostic as "file:line:column: severity: message"
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// HasErrors reports whether any diagnostic is an error
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// valuePattern is the placeholder patch-mode field patterns must contain
+var valuePattern = regexp.MustCompile(`\{value(\|\w+)?\}`)
+
+// fileDiags collects diagnostics for one file
+type fileDiags struct {
+	path  string
+	data  []byte
+	diags *[]Diagnostic
+}
+
+func (f fileDiags) add(off int, severity, msg string) {
+	line, col := lineCol(f.data, off)
+	*f.diags = append(*f.diags, Diagnostic{File: f.path, Line: line, Column: col, Severity: severity, Message: msg})
+}
+
+func (f fileDiags) errorf(off int, format string, args ...any) {
+	f.add(off, SeverityError, fmt.Sprintf(format, args...))
+}
+
+func (f fileDiags) warnf(off int, format string, args ...any) {
+	f.add(off, SeverityWarning, fmt.Sprintf(format, args...))
+}
+
+// ValidateDir checks a plugin directory's plugin.json and the spec it
+// references against the published schemas, plus the rules a schema cannot
+// express: files that must exist, min <= max, defaults that fit their type
+// and non-empty enums for select fields.
+func ValidateDir(dir string) []Diagnostic {
+	var diags []Diagnostic
+	mf := fileDiags{path: filepath.Join(dir, "plugin.json"), diags: &diags}
+	mtree, ok := parseFile(&mf)
+	if !ok {
+		return diags
+	}
+	checkSchema(mf, mtree, ManifestSchema)
+
+	var m Manifest
+	if err := json.Unmarshal(mf.data, &m); err != nil {
+		// type mismatches are already reported against the schema
+		return diags
+	}
+
+	var spec *Spec
+	if m.SpecRelPath != "" {
+		sf := fileDiags{path: filepath.Join(dir, filepath.FromSlash(m.SpecRelPath)), diags: &diags}
+		if stree, ok := parseFileAt(&sf, mf, mtree.get("spec")); ok {
+			checkSchema(sf, stree, SpecSchema)
+			var s Spec
+			if err := json.Unmarshal(sf.data, &s); err == nil {
+				spec = &s
+				checkSpec(sf, stree, s)
+			}
+		}
+	}
+	checkManifest(mf, mtree, m, spec, dir)
+	return diags
+}
+
+// parseFile reads and parses a file, reporting problems against it
+func parseFile(f *fileDiags) (*jsonNode, bool) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		*f.diags = append(*f.diags, Diagnostic{File: f.path, Severity: SeverityError, Message: readError(err)})
+		return nil, false
+	}
+	return parseData(f, data)
+}
+
+// parseFileAt is parseFile for a file referenced from another one; a file
+// that cannot be read is reported at the reference
+func parseFileAt(f *fileDiags, from fileDiags, ref *jsonNode) (*jsonNode, bool) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		from.errorf(ref.off, "spec %s", readError(err))
+		return nil, false
+	}
+	return parseData(f, data)
+}
+
+func parseData(f *fileDiags, data []byte) (*jsonNode, bool) {
+	f.data = data
+	tree, err := parseJSONTree(data)
+	if err != nil {
+		var se *errJSONSyntax
+		if errors.As(err, &se) {
+			f.errorf(se.off, "invalid JSON: %s", se.msg)
+		} else {
+			f.errorf(0, "invalid JSON: %v", err)
+		}
+		return nil, false
+	}
+	return tree, true
+}
+
+func readError(err error) string {
+	if errors.Is(err, fs.ErrNotExist) {
+		return "file not found"
+	}
+	return err.Error()
+}
+
+func checkSchema(f fileDiags, tree *jsonNode, name string) {
+	s, err := loadSchema(name)
+	if err != nil {
+		f.errorf(0, "%v", err)
+		return
+	}
+	s.check(tree, "", func(off int, msg string) { f.errorf(off, "%s", msg) })
+}
+
+// checkManifest applies the manifest rules the schema cannot express
+func checkManifest(f fileDiags, tree *jsonNode, m Manifest, spec *Spec, dir string) {
+	modeOff := tree.off
+	if n := tree.get("mode"); n != nil {
+		modeOff = n.off
+	}
+	mode := m.Mode
+	if mode == "" {
+		mode = ModeFile
+	}
+
+	switch mode {
+	case ModeInclude:
+		if m.Include == "" {
+			f.errorf(modeOff, "include mode needs an 'include' directive")
+		}
+		if len(m.Templates) == 0 {
+			f.errorf(modeOff, "include mode needs at least one template")
+		}
+	case ModePatch, ModeBlock:
+		if len(m.UserPaths) == 0 {
+			f.errorf(modeOff, "%s mode needs at least one user path", mode)
+		}
+	case ModeFile:
+		if len(m.Templates) == 0 && len(m.UserPaths) == 0 {
+			f.errorf(tree.off, "file mode needs templates or at least one user path")
+		}
+	}
+	if mode == ModePatch && spec != nil {
+		patterned := false
+		for _, fl := range spec.Fields {
+			patterned = patterned || fl.Pattern != ""
+		}
+		if !patterned {
+			f.warnf(modeOff, "patch mode but no spec field declares a pattern")
+		}
+	}
+
+	if tpls := tree.get("templates"); tpls != nil {
+		for _, k := range tpls.keys {
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(k.name))); err != nil {
+				f.errorf(k.off, "template %s: %s", k.name, readError(err))
+			}
+		}
+	}
+	if n := tree.get("verify_error"); n != nil && n.kind == kindString {
+		if _, err := regexp.Compile(n.str); err != nil {
+			f.errorf(n.off, "verify_error: %v", err)
+		}
+	}
+	for _, key := range []string{"after", "before"} {
+		if n := tree.get(key); n != nil {
+			for _, item := range n.items {
+				if strings.EqualFold(item.str, m.ID) {
+					f.warnf(item.off, "%s: a plugin cannot be ordered against itself", key)
+				}
+			}
+		}
+	}
+}
+
+// checkSpec applies the spec rules the schema cannot express
+func checkSpec(f fileDiags, tree *jsonNode, s Spec) {
+	fieldsNode := tree.get("fields")
+	if fieldsNode == nil {
+		return
+	}
+	seen := map[string]bool{}
+	for i, fl := range s.Fields {
+		if i >= len(fieldsNode.items) {
+			break
+		}
+		node := fieldsNode.items[i]
+		at := func(key string) int {
+			if n := node.get(key); n != nil {
+				return n.off
+			}
+			return node.off
+		}
+		path := fmt.Sprintf("fields[%d]", i)
+
+		if fl.Key != "" {
+			if seen[fl.Key] {
+				f.errorf(at("key"), "%s.key: duplicate field key '%s'", path, fl.Key)
+			}
+			seen[fl.Key] = true
+		}
+		if fl.Min != nil && fl.Max != nil && *fl.Min > *fl.Max {
+			f.errorf(at("min"), "%s: min %v is greater than max %v", path, *fl.Min, *fl.Max)
+		}
+		if fl.Type != "number" && (fl.Min != nil || fl.Max != nil) {
+			f.warnf(at("min"), "%s: min/max only apply to number fields", path)
+		}
+		if fl.Type == "select" && len(fl.Enum) == 0 {
+			f.errorf(at("enum"), "%s: select fields need a non-empty enum", path)
+		}
+		if fl.Type != "select" && len(fl.Enum) > 0 {
+			f.warnf(at("enum"), "%s: enum only applies to select fields", path)
+		}
+		if fl.Pattern != "" && !valuePattern.MatchString(fl.Pattern) {
+			f.errorf(at("pattern"), "%s.pattern: needs a {value} placeholder", path)
+		}
+		if node.get("default") != nil {
+			if err := checkDefault(fl); err != nil {
+				f.errorf(at("default"), "%s.default: %v", path, err)
+			}
+		}
+	}
+}
+
+// checkDefault reports a default value that its own field would reject
+func checkDefault(fl Field) error {
+	if err := fl.Validate(fl.Default); err != nil {
+		return fmt.Errorf("'%s': %v", fl.Default, err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diagStrings(dir string, diags []Diagnostic) []string {
+	out := make([]string, 0, len(diags))
+	for _, d := range diags {
+		out = append(out, strings.TrimPrefix(d.String(), dir+string(filepath.Separator)))
+	}
+	return out
+}
+
+const validSpec = `{"fields": [{"key": "bg", "type": "color", "default": "#000000"}]}`
+
+func TestValidateDir(t *testing.T) {
+	t.Run("should_accept_the_bundled_plugins", func(t *testing.T) {
+		entries, err := os.ReadDir(filepath.Join("..", "..", "plugins"))
+		require.NoError(t, err)
+		for _, e := range entries {
+			diags := ValidateDir(filepath.Join("..", "..", "plugins", e.Name()))
+			assert.Empty(t, diags, e.Name())
+		}
+	})
+
+	t.Run("should_report_syntax_errors_with_position", func(t *testing.T) {
+		dir := installPlugin(t, t.TempDir(), "demo", "{\n  \"id\": \"demo\",\n  \"spec\": \"spec.json\"\n  \"title\": \"x\"\n}", validSpec)
+
+		got := diagStrings(dir, ValidateDir(dir))
+
+		require.Len(t, got, 1)
+		assert.True(t, strings.HasPrefix(got[0], "plugin.json:4:3: error: invalid JSON"), got[0])
+	})
+
+	t.Run("should_report_schema_violations_at_the_offending_key", func(t *testing.T) {
+		dir := installPlugin(t, t.TempDir(), "demo", `{
+  "id": "demo",
+  "spec": "spec.json",
+  "user_paths": ["~/.config/demo.conf"],
+  "reloads": ["demo", "--reload"],
+  "mode": "replace",
+  "id": "again"
+}`, validSpec)
+
+		got := diagStrings(dir, ValidateDir(dir))
+
+		assert.Equal(t, []string{
+			"plugin.json:5:3: error: unknown key 'reloads' (did you mean 'reload'?)",
+			"plugin.json:6:11: error: mode: 'replace' is not one of file, patch, block, include",
+			"plugin.json:7:3: error: duplicate key 'id'",
+		}, got)
+	})
+
+	t.Run("should_report_missing_required_keys_and_spec_file", func(t *testing.T) {
+		dir := installPlugin(t, t.TempDir(), "demo", `{"spec": "missing.json", "user_paths": ["~/x"]}`, "")
+
+		got := diagStrings(dir, ValidateDir(dir))
+
+		assert.Equal(t, []string{
+			"plugin.json:1:1: error: missing required key 'id'",
+			"plugin.json:1:10: error: spec file not found",
+		}, got)
+	})
+
+	t.Run("should_check_field_rules_the_schema_cannot_express", func(t *testing.T) {
+		dir := installPlugin(t, t.TempDir(), "demo", `{"id": "demo", "spec": "spec.json", "user_paths": ["~/x"]}`, `{
+  "fields": [
+    {"key": "bg", "type": "color", "default": "black"},
+    {"key": "size", "type": "number", "min": 10, "max": 2},
+    {"key": "layout", "type": "select", "enum": []},
+    {"key": "bg", "type": "texty"}
+  ]
+}`)
+
+		got := diagStrings(dir, ValidateDir(dir))
+
+		assert.Equal(t, []string{
+			"spec.json:6:27: error: fields[3].type: 'texty' is not one of color, text, number, select",
+			"spec.json:3:47: error: fields[0].default: 'black': expect #RRGGBB",
+			"spec.json:4:46: error: fields[1]: min 10 is greater than max 2",
+			"spec.json:5:49: error: fields[2]: select fields need a non-empty enum",
+			"spec.json:6:13: error: fields[3].key: duplicate field key 'bg'",
+		}, got)
+	})
+
+	t.Run("should_require_an_include_directive_in_include_mode", func(t *testing.T) {
+		dir := installPlugin(t, t.TempDir(), "demo", `{"id": "demo", "spec": "spec.json", "mode": "include", "templates": {"t.tmpl": "~/x"}}`, validSpec)
+
+		got := diagStrings(dir, ValidateDir(dir))
+
+		assert.Equal(t, []string{
+			"plugin.json:1:45: error: include mode needs an 'include' directive",
+			"plugin.json:1:70: error: template t.tmpl: file not found",
+		}, got)
+	})
+}
+
+// The published schemas must describe exactly the keys the loader reads
+func TestSchemasMatchTypes(t *testing.T) {
+	cases := []struct {
+		schema string
+		typ    reflect.Type
+		sub    func(*schema) *schema
+	}{
+		{ManifestSchema, reflect.TypeOf(Manifest{}), func(s *schema) *schema { return s }},
+		{SpecSchema, reflect.TypeOf(Spec{}), func(s *schema) *schema { return s }},
+		{SpecSchema, reflect.TypeOf(Field{}), func(s *schema) *schema { return s.Properties["fields"].Items }},
+	}
+	for _, c := range cases {
+		s, err := loadSchema(c.schema)
+		require.NoError(t, err)
+		var declared []string
+		for k := range c.sub(s).Properties {
+			declared = append(declared, k)
+		}
+		sort.Strings(declared)
+
+		assert.Equal(t, jsonKeys(c.typ), declared, c.typ.Name())
+	}
+}
+
+func jsonKeys(typ reflect.Type) []string {
+	var keys []string
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
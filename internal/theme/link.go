@@ -0,0 +1,76 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Available lists the theme directories under themeDir
+func Available(themeDir string) ([]string, error) {
+	entries, err := os.ReadDir(themeDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		// Themes may themselves be symlinks to directories
+		info, err := os.Stat(filepath.Join(themeDir, e.Name()))
+		if err == nil && info.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Current returns the name of the theme the link points at, or "" when the
+// link does not exist
+func Current(link string) (string, error) {
+	target, err := os.Readlink(link)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	return filepath.Base(filepath.Clean(target)), nil
+}
+
+// Activate atomically points link at the named theme under themeDir by
+// creating a temporary symlink next to it and renaming it into place
+func Activate(themeDir, link, name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, filepath.Separator) {
+		return fmt.Errorf("invalid theme name '%s'", name)
+	}
+	target := filepath.Join(themeDir, name)
+	info, err := os.Stat(target)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("theme '%s' not found in %s", name, themeDir)
+	}
+
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("refusing to replace %s: it is not a symlink", link)
+	}
+	if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+		return fmt.Errorf("cannot create %s: %w", filepath.Dir(link), err)
+	}
+
+	tmp := fmt.Sprintf("%s.palettesmith-%d", link, os.Getpid())
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("failed to create link: %w", err)
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to switch theme: %w", err)
+	}
+	return nil
+}
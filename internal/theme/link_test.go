@@ -0,0 +1,96 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func themeDirs(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "themes")
+	for _, n := range names {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, n), 0o755))
+	}
+	return dir
+}
+
+func TestAvailable(t *testing.T) {
+	t.Run("should_list_theme_directories_sorted", func(t *testing.T) {
+		dir := themeDirs(t, "tokyo-night", "catppuccin")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("x"), 0o644))
+
+		names, err := Available(dir)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"catppuccin", "tokyo-night"}, names)
+	})
+
+	t.Run("should_return_nothing_for_missing_dir", func(t *testing.T) {
+		names, err := Available(filepath.Join(t.TempDir(), "nope"))
+
+		require.NoError(t, err)
+		assert.Empty(t, names)
+	})
+}
+
+func TestActivate(t *testing.T) {
+	t.Run("should_create_and_retarget_the_link", func(t *testing.T) {
+		dir := themeDirs(t, "catppuccin", "nord")
+		link := filepath.Join(t.TempDir(), "current", "theme")
+
+		require.NoError(t, Activate(dir, link, "catppuccin"))
+		name, err := Current(link)
+		require.NoError(t, err)
+		assert.Equal(t, "catppuccin", name)
+
+		require.NoError(t, Activate(dir, link, "nord"))
+		name, err = Current(link)
+		require.NoError(t, err)
+		assert.Equal(t, "nord", name)
+
+		entries, err := os.ReadDir(filepath.Dir(link))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary links must not be left behind")
+	})
+
+	t.Run("should_reject_unknown_themes", func(t *testing.T) {
+		dir := themeDirs(t, "catppuccin")
+
+		err := Activate(dir, filepath.Join(t.TempDir(), "theme"), "nord")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("should_reject_path_like_names", func(t *testing.T) {
+		dir := themeDirs(t, "catppuccin")
+
+		err := Activate(dir, filepath.Join(t.TempDir(), "theme"), "../catppuccin")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should_refuse_to_replace_a_real_directory", func(t *testing.T) {
+		dir := themeDirs(t, "catppuccin")
+		link := filepath.Join(t.TempDir(), "theme")
+		require.NoError(t, os.MkdirAll(link, 0o755))
+
+		err := Activate(dir, link, "catppuccin")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not a symlink")
+	})
+}
+
+func TestCurrent(t *testing.T) {
+	t.Run("should_return_empty_when_link_missing", func(t *testing.T) {
+		name, err := Current(filepath.Join(t.TempDir(), "theme"))
+
+		require.NoError(t, err)
+		assert.Empty(t, name)
+	})
+}
@@ -0,0 +1,84 @@
+package theme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// PaletteFile holds a theme's palette, as the "defaults" of a ThemeConfig,
+// inside the theme's directory
+const PaletteFile = "palette.json"
+
+// Load builds the store values are resolved from: the palette of the theme
+// link points at, or DefaultConfig when no theme with a palette is active,
+// with the overrides saved at overridesPath on top
+func Load(link, overridesPath string) (*Store, error) {
+	s := NewStore(DefaultConfig())
+
+	name, err := Current(link)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		var pal ThemeConfig
+		ok, err := readJSON(filepath.Join(link, PaletteFile), &pal)
+		if err != nil {
+			return nil, fmt.Errorf("theme '%s': %w", name, err)
+		}
+		if ok {
+			s = NewStore(ThemeConfig{ThemeDefaults: pal.ThemeDefaults})
+			s.Theme = name
+		}
+	}
+
+	var saved ThemeConfig
+	if _, err := readJSON(overridesPath, &saved); err != nil {
+		return nil, fmt.Errorf("saved overrides: %w", err)
+	}
+	for target, vals := range saved.TargetOverrides {
+		for key, v := range vals {
+			s.SetOverride(target, key, v)
+		}
+	}
+	return s, nil
+}
+
+// SaveOverrides writes the store's per-target overrides to path, where Load
+// picks them up, replacing the file atomically
+func SaveOverrides(path string, s *Store) error {
+	data, err := json.MarshalIndent(ThemeConfig{TargetOverrides: s.Cfg.TargetOverrides}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// readJSON decodes path into v, reporting false when the file does not exist
+func readJSON(path string, v any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	return true, nil
+}
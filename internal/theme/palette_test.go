@@ -0,0 +1,55 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("should_use_the_active_palette_and_saved_overrides", func(t *testing.T) {
+		dir := themeDirs(t, "nord")
+		link := filepath.Join(t.TempDir(), "current")
+		require.NoError(t, Activate(dir, link, "nord"))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "nord", PaletteFile), []byte(`{"defaults": {"bg": "#2e3440"}}`), 0o644))
+		overrides := filepath.Join(t.TempDir(), "overrides.json")
+		saved := NewStore(ThemeConfig{})
+		saved.SetOverride("kitty", "fg", "#eceff4")
+		require.NoError(t, SaveOverrides(overrides, saved))
+
+		s, err := Load(link, overrides)
+
+		require.NoError(t, err)
+		assert.Equal(t, "nord", s.Theme)
+		assert.Equal(t, "#2e3440", s.Resolve("kitty", "bg", "#000000"))
+		assert.Equal(t, "#eceff4", s.Resolve("kitty", "fg", "#ffffff"))
+		assert.Equal(t, "#000000", s.Resolve("kitty", "accent", "#000000"), "keys missing from the palette use the field default")
+	})
+
+	t.Run("should_fall_back_to_the_builtin_palette", func(t *testing.T) {
+		dir := themeDirs(t, "bare")
+		link := filepath.Join(t.TempDir(), "current")
+		require.NoError(t, Activate(dir, link, "bare"))
+
+		s, err := Load(link, filepath.Join(t.TempDir(), "missing.json"))
+
+		require.NoError(t, err)
+		assert.Empty(t, s.Theme, "a theme without a palette contributes no values")
+		assert.Equal(t, DefaultConfig().ThemeDefaults["bg"], s.Resolve("x", "bg", ""))
+	})
+
+	t.Run("should_report_a_malformed_palette", func(t *testing.T) {
+		dir := themeDirs(t, "broken")
+		link := filepath.Join(t.TempDir(), "current")
+		require.NoError(t, Activate(dir, link, "broken"))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "broken", PaletteFile), []byte(`{`), 0o644))
+
+		_, err := Load(link, "")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "theme 'broken'")
+	})
+}
@@ -6,8 +6,24 @@ type ThemeConfig struct {
 	TargetOverrides map[string]map[string]string `json:"overrides,omitempty"`
 }
 
+// DefaultConfig is the built-in palette used until a theme is loaded
+func DefaultConfig() ThemeConfig {
+	return ThemeConfig{
+		ThemeDefaults: map[string]string{
+			"bg":     "#1e1e2e",
+			"fg":     "#cdd6f4",
+			"accent": "#89b4fa",
+			"border": "#45475a",
+		},
+	}
+}
+
 type Store struct {
 	Cfg ThemeConfig
+
+	// Theme names the theme the defaults were loaded from; empty for the
+	// built-in palette
+	Theme string
 }
 
 func NewStore(seed ThemeConfig) *Store {
@@ -20,14 +36,27 @@ func NewStore(seed ThemeConfig) *Store {
 	return &Store{Cfg: seed}
 }
 
+// Layers a resolved value can come from, most specific first
+const (
+	SourceOverride = "override" // per-target override
+	SourceTheme    = "theme"    // theme-wide default
+	SourceField    = "field"    // the plugin field's own default
+)
+
 func (s *Store) Resolve(targetID, fieldKey, fieldDefault string) string {
+	v, _ := s.ResolveSource(targetID, fieldKey, fieldDefault)
+	return v
+}
+
+// ResolveSource resolves a value like Resolve and reports the layer it came from
+func (s *Store) ResolveSource(targetID, fieldKey, fieldDefault string) (string, string) {
 	if v := s.GetOverride(targetID, fieldKey); v != "" {
-		return v
+		return v, SourceOverride
 	}
 	if v, ok := s.Cfg.ThemeDefaults[fieldKey]; ok {
-		return v
+		return v, SourceTheme
 	}
-	return fieldDefault
+	return fieldDefault, SourceField
 }
 
 func (s *Store) GetOverride(targetID, fieldKey string) string {
@@ -0,0 +1,29 @@
+package theme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSource(t *testing.T) {
+	st := NewStore(ThemeConfig{ThemeDefaults: map[string]string{"bg": "#111111", "fg": "#222222"}})
+	st.SetOverride("waybar", "fg", "#333333")
+
+	t.Run("should_prefer_target_overrides", func(t *testing.T) {
+		v, src := st.ResolveSource("waybar", "fg", "#000000")
+
+		assert.Equal(t, "#333333", v)
+		assert.Equal(t, SourceOverride, src)
+	})
+
+	t.Run("should_fall_back_to_theme_then_field_default", func(t *testing.T) {
+		v, src := st.ResolveSource("waybar", "bg", "#000000")
+		assert.Equal(t, "#111111", v)
+		assert.Equal(t, SourceTheme, src)
+
+		v, src = st.ResolveSource("waybar", "accent", "#000000")
+		assert.Equal(t, "#000000", v)
+		assert.Equal(t, SourceField, src)
+	})
+}
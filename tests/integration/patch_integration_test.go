@@ -0,0 +1,45 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"palettesmith/internal/apply"
+	"palettesmith/internal/config"
+	"palettesmith/internal/plugin"
+)
+
+// TestPatchModeKeepsHandWrittenConfig applies a patch-mode plugin to a copy of
+// the hyprland fixture and checks that only the patched values changed
+func TestPatchModeKeepsHandWrittenConfig(t *testing.T) {
+	original, err := os.ReadFile(filepath.Join("..", "fixtures", "configs", "hyprland.conf"))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	live := filepath.Join(dir, "hyprland.conf")
+	require.NoError(t, os.WriteFile(live, original, 0o644))
+
+	plug := plugin.Plugin{
+		Manifest: plugin.Manifest{ID: "hyprland", Mode: plugin.ModePatch, UserPaths: []string{live}},
+		Spec: plugin.Spec{Fields: []plugin.Field{
+			{Key: "border_size", Type: "number", Default: "2", Pattern: "border_size = {value}"},
+			// A Hyprland border is a gradient, not a single #RRGGBB colour
+			{Key: "active_border", Type: "text", Default: "rgb(89b4fa)", Pattern: "col.active_border = {value}"},
+		}},
+	}
+
+	cfg := config.Config{StagingDir: filepath.Join(dir, "staging")}
+	_, err = apply.Run(cfg, plug, map[string]string{"border_size": "3", "active_border": "rgb(89b4fa)"})
+	require.NoError(t, err)
+
+	patched, err := os.ReadFile(live)
+	require.NoError(t, err)
+
+	want := strings.Replace(string(original), "border_size = 1", "border_size = 3", 1)
+	want = strings.Replace(want, "col.active_border = rgba(33ccffee) rgba(00ff99ee) 45deg", "col.active_border = rgb(89b4fa)", 1)
+	assert.Equal(t, want, string(patched))
+}
@@ -2,13 +2,21 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"palettesmith/internal/apply"
+	"palettesmith/internal/config"
+	"palettesmith/internal/paths"
 	"palettesmith/internal/plugin"
 	"palettesmith/internal/theme"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -18,6 +26,9 @@ type page int
 const (
 	pageExplainer page = iota
 	pageForm
+	pageDiff
+	pagePlan
+	pageHistory
 )
 
 const sidebarW = 30
@@ -29,12 +40,22 @@ type Model struct {
 	store         *plugin.Store
 	form          formModel
 	specLoadedFor string
+	diffView      viewport.Model
+	diffFor       string
+	planView      viewport.Model
+	planFor       string
+	historyView   viewport.Model
 	status        string
+	statusErr     bool
+	applying      bool
+	activeTheme   string
+	conflict      *pendingConflict // apply waiting for the user to resolve external edits
 
+	cfg   config.Config
 	theme *theme.Store
 }
 
-func New() Model {
+func New(cfg config.Config) Model {
 	st, _ := plugin.Discover()
 
 	items := []list.Item{}
@@ -46,24 +67,28 @@ func New() Model {
 		})
 	}
 
+	items = append(items, problemItems(st)...)
+
 	if len(items) == 0 {
-		items = []list.Item{targetItem{id: "", title: "No plugins found", description: "Put plugins under ./plugins/<id>/"}}
+		items = []list.Item{targetItem{id: "", title: "No plugins found", description: "Put plugins under ~/.config/palettesmith/plugins/<id>/"}}
 	}
 
-	th := theme.NewStore(theme.ThemeConfig{
-		ThemeDefaults: map[string]string{
-			"bg":     "#1e1e2e",
-			"fg":     "#cdd6f4",
-			"accent": "#89b4fa",
-			"border": "#45475a",
-		},
-	})
+	th, err := loadTheme(cfg)
+	if err != nil {
+		th = theme.NewStore(theme.DefaultConfig())
+	}
+	active, _ := theme.Current(cfg.CurrentThemeLink)
 
 	return Model{
-		sidebar: NewSidebar(items),
-		page:    pageExplainer,
-		store:   st,
-		theme:   th,
+		sidebar:     NewSidebar(items),
+		page:        pageExplainer,
+		diffView:    newDiffViewport(),
+		planView:    newDiffViewport(),
+		historyView: newDiffViewport(),
+		store:       st,
+		cfg:         cfg,
+		theme:       th,
+		activeTheme: active,
 	}
 }
 
@@ -80,49 +105,250 @@ func (m Model) ensureFormFor(id string) Model {
 	return m
 }
 
+// refreshDiff re-renders the pending changes for the selected target
+func (m Model) refreshDiff() Model {
+	id := m.sidebar.SelectedID()
+	if m.diffFor == id && id != "" {
+		return m
+	}
+	m.diffFor = id
+	var plug plugin.Plugin
+	ok := false
+	if m.store != nil {
+		plug, ok = m.store.Get(id)
+	}
+	if !ok {
+		m.diffView.SetContent(helpStyle.Render("Select a target to preview its changes."))
+		return m
+	}
+	diffs, err := apply.Preview(plug, apply.Values(plug, m.theme))
+	if err != nil {
+		m.diffView.SetContent(diffDelStyle.Render("Cannot preview: " + err.Error()))
+		return m
+	}
+	m.diffView.SetContent(renderPreview(diffs))
+	m.diffView.GotoTop()
+	return m
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
-		m.sidebar.SetSize(sidebarW, m.height)
+		// One line is taken by the header
+		m.sidebar.SetSize(sidebarW, m.height-1)
+		m.diffView.Width = max(40, m.width-sidebarW) - 4
+		m.diffView.Height = max(1, m.height-9)
+		m.planView.Width = m.diffView.Width
+		m.planView.Height = m.diffView.Height
+		m.historyView.Width = m.diffView.Width
+		m.historyView.Height = m.diffView.Height
 
 	case tea.KeyMsg:
+		if m.conflict != nil {
+			return m.resolveConflict(msg)
+		}
+		// An open select list takes every key, including ones bound globally
+		if m.page == pageForm && m.form.Picking() {
+			var cmd tea.Cmd
+			m.form, cmd = m.form.Update(msg)
+			return m, cmd
+		}
 		switch msg.String() {
-		case "q", "ctrl+c":
+		case "ctrl+c":
 			return m, tea.Quit
+		case "q":
+			if !m.typing() {
+				return m, tea.Quit
+			}
 		case "tab":
-			if m.page == pageExplainer {
+			switch m.page {
+			case pageExplainer:
 				m.page = pageForm
-			} else {
+			case pageForm:
+				m.page = pageDiff
+			case pageDiff:
+				m.page = pagePlan
+			case pagePlan:
+				m.page = pageHistory
+				m = m.refreshHistory()
+			default:
 				m.page = pageExplainer
 			}
-		case "a":
-			sel := m.sidebar.SelectedID()
-			if sel == "" {
+			// Form edits may have changed the values since the last preview
+			m.diffFor = ""
+			m.planFor = ""
+		case "a", "ctrl+s":
+			// ctrl+s also applies while a text field or filter takes letters
+			if msg.String() == "a" && m.typing() {
+				break
+			}
+			if m.applying {
+				m.status = "Apply already in progress"
+				return m, clearAfter(2 * time.Second)
+			}
+
+			ids := m.targetIDs()
+			if len(ids) == 0 {
 				m.status = "No target selected"
 				return m, clearAfter(2 * time.Second)
 			}
 
-			if plug, ok := m.store.Get(sel); ok {
-				eff := map[string]string{}
-				for _, f := range plug.Spec.Fields {
-					eff[f.Key] = m.theme.Resolve(sel, f.Key, f.Default)
+			targets := make([]apply.Target, 0, len(ids))
+			for _, id := range ids {
+				plug, ok := m.store.Get(id)
+				if !ok {
+					m.status = fmt.Sprintf("Unknown target %s", id)
+					return m, clearAfter(2 * time.Second)
+				}
+				targets = append(targets, apply.Target{Plugin: plug, Values: apply.Values(plug, m.theme)})
+			}
+			// Keep the edited values for the next session and the CLI
+			if err := m.saveOverrides(); err != nil {
+				m.status = fmt.Sprintf("Failed to save values: %v", err)
+				m.statusErr = true
+				return m, clearAfter(statusTimeout(true))
+			}
+			m.applying = true
+			m.status = fmt.Sprintf("Applying %s…", strings.Join(ids, ", "))
+			return m, applyCmd(m.cfg, targets, m.applyOptions())
+		case " ":
+			if m.page != pageForm && !m.typing() {
+				m.sidebar.ToggleMark()
+				if m.page == pagePlan {
+					m = m.refreshPlan()
+				}
+				return m, nil
+			}
+		case "*":
+			if m.page != pageForm && !m.typing() {
+				m.sidebar.ToggleMarkAll()
+				if m.page == pagePlan {
+					m = m.refreshPlan()
+				}
+				return m, nil
+			}
+		case "R":
+			if m.typing() {
+				break
+			}
+			if m.applying {
+				m.status = "Apply already in progress"
+				return m, clearAfter(2 * time.Second)
+			}
+			m.applying = true
+			m.status = "Rolling back…"
+			return m, rollbackCmd(m.cfg, m.store)
+		case "T":
+			if m.typing() {
+				break
+			}
+			next, err := nextTheme(m.cfg.TargetThemeDir, m.activeTheme)
+			if err == nil {
+				err = theme.Activate(m.cfg.TargetThemeDir, m.cfg.CurrentThemeLink, next)
+			}
+			if err != nil {
+				m.status = fmt.Sprintf("Theme switch failed: %v", err)
+				m.statusErr = true
+				return m, clearAfter(statusTimeout(true))
+			}
+			m.activeTheme = next
+			// Resolve against the new palette, keeping the edits made so far
+			if th, err := loadTheme(m.cfg); err == nil {
+				for target, vals := range m.theme.Cfg.TargetOverrides {
+					for key, v := range vals {
+						th.SetOverride(target, key, v)
+					}
 				}
-				m.status = fmt.Sprintf("Apply (dry-run) %s: %v", sel, eff)
-			} else {
-				m.status = "Unknown target"
+				m.theme = th
+				m.specLoadedFor = ""
+				m.diffFor = ""
+				m.planFor = ""
 			}
+			m.status = fmt.Sprintf("Activated theme %s", next)
+			m.statusErr = false
 			return m, clearAfter(2 * time.Second)
 		}
+	case rollbackDoneMsg:
+		m.applying = false
+		m.diffFor = ""
+		m.planFor = ""
+		m.statusErr = msg.err != nil || msg.reloadErr != nil
+		switch {
+		case errors.Is(msg.err, apply.ErrNoBackups):
+			m.status = "Nothing to roll back"
+		case msg.err != nil:
+			m.status = fmt.Sprintf("Rollback failed: %v", msg.err)
+		case msg.reloadErr != nil:
+			m.status = fmt.Sprintf("Restored backup %s but %v", msg.gen.ID, msg.reloadErr)
+		default:
+			m.status = fmt.Sprintf("Restored backup %s", msg.gen.ID)
+		}
+		return m, clearAfter(statusTimeout(m.statusErr))
+	case applyDoneMsg:
+		m.applying = false
+		m.diffFor = ""
+		m.planFor = ""
+		var ce *apply.ConflictError
+		if errors.As(msg.err, &ce) {
+			m.conflict = &pendingConflict{targets: msg.targets, conflicts: ce.Conflicts}
+			m.status = fmt.Sprintf("%d file(s) changed outside palettesmith", len(ce.Conflicts))
+			m.statusErr = true
+			return m, nil
+		}
+		failed := msg.batch.ReloadFailures()
+		rejected := msg.batch.VerifyFailures()
+		var warnings []string
+		kept := 0
+		for _, r := range msg.batch.Results {
+			warnings = append(warnings, r.Warnings...)
+			kept += len(r.Skipped)
+		}
+		m.statusErr = msg.err != nil || len(failed) > 0 || len(rejected) > 0
+		switch {
+		case msg.err != nil:
+			m.status = fmt.Sprintf("Apply failed: %v", msg.err)
+		case len(rejected) > 0 && msg.batch.Backup == "":
+			m.status = fmt.Sprintf("Nothing applied: %s", rejected[0].Summary())
+		case len(rejected) > 0:
+			m.status = fmt.Sprintf("Applied (backup %s) but %s", msg.batch.Backup, rejected[0].Summary())
+		case len(failed) > 0:
+			m.status = fmt.Sprintf("Applied %s but %s", msg.batch.Targets(), failed[0].Summary())
+		case len(warnings) > 0:
+			m.status = fmt.Sprintf("Applied %s with %d warning(s): %s", msg.batch.Targets(), len(warnings), warnings[0])
+		case msg.batch.Backup == "" && kept > 0:
+			m.status = fmt.Sprintf("Kept external edits; nothing written for %s", msg.batch.Targets())
+		case msg.batch.Backup == "":
+			m.status = fmt.Sprintf("%s already up to date", msg.batch.Targets())
+		default:
+			m.status = fmt.Sprintf("Applied %s (backup %s)", msg.batch.Targets(), msg.batch.Backup)
+		}
+		if msg.err == nil {
+			m.sidebar.ClearMarks()
+		}
+		if m.page == pageHistory {
+			m = m.refreshHistory()
+		}
+		return m, clearAfter(statusTimeout(m.statusErr))
 	case statusClearMsg:
 		m.status = ""
+		m.statusErr = false
 	}
 
 	var cmd tea.Cmd
 	m.sidebar, cmd = m.sidebar.Update(msg)
-	if m.page == pageForm {
+	switch m.page {
+	case pageForm:
 		m = m.ensureFormFor(m.sidebar.SelectedID())
 		m.form, cmd = m.form.Update(msg)
+	case pageDiff:
+		m = m.refreshDiff()
+		m.diffView, cmd = m.diffView.Update(msg)
+	case pagePlan:
+		m = m.refreshPlan()
+		m.planView, cmd = m.planView.Update(msg)
+	case pageHistory:
+		m.historyView, cmd = m.historyView.Update(msg)
 	}
 	return m, cmd
 }
@@ -145,6 +371,7 @@ var (
 )
 
 func (m Model) View() string {
+	header := titleStyle.Render("Palettesmith") + helpStyle.Render(" · theme: ") + nz(m.activeTheme, "none")
 	left := leftPane.Width(sidebarW).Render(m.sidebar.View())
 	rightWidth := max(40, m.width-sidebarW)
 
@@ -157,24 +384,44 @@ func (m Model) View() string {
 		boolStyle(m.page == pageExplainer, tabActive, tabDim).Render("Explainer"),
 		lipgloss.NewStyle().Padding(0, 1).Render("·"),
 		boolStyle(m.page == pageForm, tabActive, tabDim).Render("Form"),
+		lipgloss.NewStyle().Padding(0, 1).Render("·"),
+		boolStyle(m.page == pageDiff, tabActive, tabDim).Render("Diff"),
+		lipgloss.NewStyle().Padding(0, 1).Render("·"),
+		boolStyle(m.page == pagePlan, tabActive, tabDim).Render("Plan"),
+		lipgloss.NewStyle().Padding(0, 1).Render("·"),
+		boolStyle(m.page == pageHistory, tabActive, tabDim).Render("History"),
 	)
 
 	selID := m.sidebar.SelectedID()
-	var upaths, spaths, reload string
+	var upaths, spaths, reload, verify, templates, mode, root string
 	if selID != "" && m.store != nil {
 		if plug, ok := m.store.Get(selID); ok {
-			upaths = strings.Join(plug.Manifest.UserPaths, ", ")
-			spaths = strings.Join(plug.Manifest.SystemPaths, ", ")
+			upaths = describePaths(plug.Manifest.UserPaths)
+			spaths = describePaths(plug.Manifest.SystemPaths)
 			reload = strings.Join(plug.Manifest.Reload, " ")
+			verify = strings.Join(plug.Manifest.Verify, " ")
+			templates = describeTemplates(plug.Manifest.Templates)
+			mode = firstNonEmpty(plug.Manifest.Mode, plugin.ModeFile)
+			root = plug.Root
 		}
 	}
 	var body string
-	switch m.page {
-	case pageExplainer:
-		body = fmt.Sprintf("%s\n\nThis target is provided by a plugin.\n• User paths: %s\n• System paths: %s\n• Reload: %s\n",
-			titleStyle.Render(title), nz(upaths, "—"), nz(spaths, "—"), nz(reload, "—"))
-	case pageForm:
+	switch {
+	case m.conflict != nil:
+		body = titleStyle.Render("Changed outside palettesmith") + "\n\n" + m.conflict.View()
+	case m.page == pageExplainer && hasProblem(m.sidebar):
+		body = describeProblem(m.sidebar)
+	case m.page == pageExplainer:
+		body = fmt.Sprintf("%s\n\nThis target is provided by a plugin.\n• User paths: %s\n• System paths: %s\n• Verify: %s\n• Reload: %s\n• Mode: %s\n• Templates: %s\n• Root: %s\n",
+			titleStyle.Render(title), nz(upaths, "—"), nz(spaths, "—"), nz(verify, "—"), nz(reload, "—"), nz(mode, "—"), nz(templates, "—"), nz(root, "—"))
+	case m.page == pageForm:
 		body = titleStyle.Render(title) + "\n\n" + m.form.View()
+	case m.page == pageDiff:
+		body = titleStyle.Render(title) + "\n\n" + m.diffView.View()
+	case m.page == pagePlan:
+		body = titleStyle.Render("Dry run") + "\n\n" + m.planView.View()
+	case m.page == pageHistory:
+		body = titleStyle.Render("Apply history") + "\n\n" + m.historyView.View()
 	}
 
 	body = tabs + "\n\n" + body
@@ -183,18 +430,35 @@ func (m Model) View() string {
 
 	statusLine := ""
 	if m.status != "" {
+		color := lipgloss.Color("#8ece6a")
+		if m.statusErr {
+			color = lipgloss.Color("#ff6b6b")
+		}
 		statusLine = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#8ece6a")).
+			Foreground(color).
 			Render(m.status) + "\n"
 	}
 	var footerText string
-	if m.page == pageForm {
-		footerText = "Enter to edit • ↑/↓ Move • A Apply • Q Quit"
-	} else {
-		footerText = "Tab Explainer/Form • ↑/↓ Move • Q Quit • / Filter"
+	switch {
+	case m.conflict != nil:
+		footerText = "O Overwrite • K Keep theirs • M Merge into managed block • Esc Cancel"
+	case m.page == pageForm && m.form.Picking():
+		footerText = "Type to filter • ↑/↓ Move • Enter Choose • Esc Close"
+	case m.page == pageForm && m.form.FocusedSelect():
+		footerText = "←/→ Cycle • Enter List • ↑/↓ Move • A Apply • R Rollback • Q Quit"
+	case m.page == pageForm:
+		footerText = "Type to edit • ↑/↓ Move • Ctrl+S Apply • Tab Diff • Ctrl+C Quit"
+	case m.page == pageDiff:
+		footerText = "Tab Plan • Ctrl+D/U Scroll • A Apply • R Rollback • Q Quit"
+	case m.page == pagePlan:
+		footerText = "Tab History • Space Mark • Ctrl+D/U Scroll • A Apply • R Rollback • Q Quit"
+	case m.page == pageHistory:
+		footerText = "Tab Explainer • Ctrl+D/U Scroll • A Apply • R Rollback • Q Quit"
+	default:
+		footerText = "Tab Explainer/Form/Diff/Plan/History • ↑/↓ Move • Space Mark • * Mark all • A Apply • R Rollback • T Theme • Q Quit • / Filter"
 	}
 	footer := helpStyle.Render(footerText)
-	return lipgloss.JoinHorizontal(lipgloss.Top, left, right) + "\n" + statusLine + footer + "\n"
+	return header + "\n" + lipgloss.JoinHorizontal(lipgloss.Top, left, right) + "\n" + statusLine + footer + "\n"
 }
 
 func max(a, b int) int {
@@ -218,12 +482,202 @@ func nz(s, fallback string) string {
 	return s
 }
 
+// problemItems lists the plugins that failed to load, then the shadowed ones
+func problemItems(st *plugin.Store) []list.Item {
+	var items []list.Item
+	for _, p := range st.Errors() {
+		items = append(items, targetItem{
+			title:       firstNonEmpty(p.ID, filepath.Base(p.Dir)),
+			description: "Failed to load",
+			problem:     p.Err.Error(),
+			dir:         p.Dir,
+		})
+	}
+	for _, p := range st.Warnings() {
+		items = append(items, targetItem{
+			title:       firstNonEmpty(p.ID, filepath.Base(p.Dir)),
+			description: "Shadowed duplicate",
+			problem:     p.Err.Error(),
+			shadowed:    true,
+			dir:         p.Dir,
+		})
+	}
+	return items
+}
+
+func hasProblem(s Sidebar) bool {
+	_, ok := s.SelectedProblem()
+	return ok
+}
+
+// describeProblem explains why the selected plugin is not available
+func describeProblem(s Sidebar) string {
+	it, _ := s.SelectedProblem()
+	what := "This plugin could not be loaded, so it is not offered as a target."
+	style := diffDelStyle
+	if it.shadowed {
+		what = "Another plugin with the same id takes precedence, so this one is ignored."
+		style = lipgloss.NewStyle().Foreground(problemWarnColor)
+	}
+	return fmt.Sprintf("%s\n\n%s\n• Dir: %s\n• Reason: %s\n",
+		titleStyle.Render(it.title), what, it.dir, style.Render(it.problem))
+}
+
+// nextTheme returns the theme after current in the theme dir, wrapping around
+func nextTheme(themeDir, current string) (string, error) {
+	names, err := theme.Available(themeDir)
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no themes in %s", themeDir)
+	}
+	for i, n := range names {
+		if n == current {
+			return names[(i+1)%len(names)], nil
+		}
+	}
+	return names[0], nil
+}
+
+// describePaths shows where each declared path resolves and whether it exists
+func describePaths(ps []string) string {
+	parts := make([]string, 0, len(ps))
+	for _, p := range ps {
+		matches, err := paths.Glob(p)
+		switch {
+		case err != nil:
+			parts = append(parts, fmt.Sprintf("%s (%v)", p, err))
+		case len(matches) > 1:
+			parts = append(parts, fmt.Sprintf("%s (+%d more)", matches[0], len(matches)-1))
+		case len(matches) == 1:
+			parts = append(parts, matches[0])
+		default:
+			abs, _ := paths.Expand(p)
+			parts = append(parts, abs+" (missing)")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeTemplates(tpls map[string]string) string {
+	srcs := make([]string, 0, len(tpls))
+	for src := range tpls {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+	parts := make([]string, 0, len(srcs))
+	for _, src := range srcs {
+		parts = append(parts, src+" → "+tpls[src])
+	}
+	return strings.Join(parts, ", ")
+}
+
 func clearAfter(d time.Duration) tea.Cmd {
 	return tea.Tick(d, func(time.Time) tea.Msg { return statusClearMsg{} })
 }
 
+// statusTimeout keeps failures on screen longer than confirmations
+func statusTimeout(isErr bool) time.Duration {
+	if isErr {
+		return 8 * time.Second
+	}
+	return 4 * time.Second
+}
+
 type statusClearMsg struct{}
 
+type applyDoneMsg struct {
+	targets []apply.Target
+	batch   apply.Batch
+	err     error
+}
+
+type rollbackDoneMsg struct {
+	gen       apply.Generation
+	err       error
+	reloadErr error
+}
+
+// rollbackCmd restores the latest backup and reloads the affected plugins
+func rollbackCmd(cfg config.Config, st *plugin.Store) tea.Cmd {
+	return func() tea.Msg {
+		l, err := config.Lock()
+		if err != nil {
+			return rollbackDoneMsg{err: err}
+		}
+		defer l.Release()
+
+		gen, err := apply.Rollback(cfg.StagingDir, "")
+		if err != nil {
+			return rollbackDoneMsg{err: err}
+		}
+		var reloadErr error
+		if st != nil {
+			reloadErr = apply.ReloadError(apply.ReloadPlugins(context.Background(), st, gen.Plugins))
+		}
+		return rollbackDoneMsg{gen: gen, reloadErr: reloadErr}
+	}
+}
+
+// typing reports whether printable keys belong to a form text field or
+// the sidebar filter
+func (m Model) typing() bool {
+	return m.sidebar.Filtering() || m.page == pageForm && m.form.Typing()
+}
+
+// targetIDs returns the marked targets, or the selected one when none are marked
+func (m Model) targetIDs() []string {
+	if ids := m.sidebar.MarkedIDs(); len(ids) > 0 {
+		return ids
+	}
+	if sel := m.sidebar.SelectedID(); sel != "" {
+		return []string{sel}
+	}
+	return nil
+}
+
+// loadTheme loads the active theme's palette and the saved overrides
+func loadTheme(cfg config.Config) (*theme.Store, error) {
+	overrides, err := config.OverridesPath()
+	if err != nil {
+		return nil, err
+	}
+	return theme.Load(cfg.CurrentThemeLink, overrides)
+}
+
+// saveOverrides persists the values edited in the form
+func (m Model) saveOverrides() error {
+	path, err := config.OverridesPath()
+	if err != nil {
+		return err
+	}
+	return theme.SaveOverrides(path, m.theme)
+}
+
+// applyOptions records applies in the journal under the theme whose palette
+// the values were resolved from
+func (m Model) applyOptions() apply.Options {
+	opts := apply.Options{Theme: m.theme.Theme}
+	if journal, err := config.HistoryPath(); err == nil {
+		opts.Journal = journal
+	}
+	return opts
+}
+
+func applyCmd(cfg config.Config, targets []apply.Target, opts apply.Options) tea.Cmd {
+	return func() tea.Msg {
+		l, err := config.Lock()
+		if err != nil {
+			return applyDoneMsg{targets: targets, err: err}
+		}
+		defer l.Release()
+
+		batch, err := apply.RunAll(cfg, targets, opts)
+		return applyDoneMsg{targets: targets, batch: batch, err: err}
+	}
+}
+
 func boolStyle(ok bool, a, b lipgloss.Style) lipgloss.Style {
 	if ok {
 		return a
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+
+	"palettesmith/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newModel starts the TUI with only the built-in plugins and empty state
+func newModel(t *testing.T) Model {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_DIRS", t.TempDir())
+	t.Setenv("PALETTESMITH_PLUGIN_PATH", "")
+	dir := t.TempDir()
+	return New(config.Config{StagingDir: filepath.Join(dir, "staging"), TargetThemeDir: filepath.Join(dir, "themes")})
+}
+
+// formPage opens the form of the built-in Hyprland target, focused on its
+// first (colour) field
+func formPage(t *testing.T) Model {
+	t.Helper()
+	next, _ := newModel(t).Update(tea.KeyMsg{Type: tea.KeyTab})
+	m := next.(Model)
+	require.Equal(t, pageForm, m.page)
+	require.True(t, m.form.Typing())
+	return m
+}
+
+func TestModel_FormKeys(t *testing.T) {
+	t.Run("should_type_action_letters_into_the_focused_field", func(t *testing.T) {
+		m := formPage(t)
+		before := m.form.Palette()["bg"]
+
+		for _, r := range "aqRT" {
+			next, _ := m.Update(keyPress(string(r)))
+			m = next.(Model)
+		}
+
+		assert.False(t, m.applying, "typing 'a' or 'R' must not apply or roll back")
+		assert.Empty(t, m.activeTheme, "typing 'T' must not switch themes")
+		assert.Equal(t, before+"aqRT", m.form.Palette()["bg"])
+	})
+
+	t.Run("should_apply_with_ctrl_s_while_typing", func(t *testing.T) {
+		m := formPage(t)
+
+		next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+
+		assert.True(t, next.(Model).applying)
+		assert.NotNil(t, cmd)
+	})
+}
+
+// filtering opens the sidebar filter on the explainer page
+func filtering(t *testing.T) Model {
+	t.Helper()
+	next, _ := newModel(t).Update(keyPress("/"))
+	m := next.(Model)
+	require.True(t, m.sidebar.Filtering())
+	return m
+}
+
+func TestModel_FilterKeys(t *testing.T) {
+	t.Run("should_type_R_into_the_sidebar_filter", func(t *testing.T) {
+		m := filtering(t)
+
+		next, _ := m.Update(keyPress("R"))
+
+		assert.False(t, next.(Model).applying, "typing 'R' must not roll back")
+		assert.Equal(t, "R", next.(Model).sidebar.l.FilterValue())
+	})
+
+	t.Run("should_type_T_into_the_sidebar_filter", func(t *testing.T) {
+		m := filtering(t)
+
+		next, _ := m.Update(keyPress("T"))
+
+		assert.Empty(t, next.(Model).activeTheme, "typing 'T' must not switch themes")
+		assert.Equal(t, "T", next.(Model).sidebar.l.FilterValue())
+	})
+
+	t.Run("should_type_mark_keys_into_the_sidebar_filter", func(t *testing.T) {
+		m := filtering(t)
+
+		for _, r := range "h *" {
+			next, _ := m.Update(keyPress(string(r)))
+			m = next.(Model)
+		}
+
+		assert.Empty(t, m.sidebar.MarkedIDs())
+		assert.Equal(t, "h *", m.sidebar.l.FilterValue())
+	})
+}
+
+func TestModel_ConflictKeys(t *testing.T) {
+	t.Run("should_accept_the_footer_keys_in_either_case", func(t *testing.T) {
+		for _, k := range []string{"O", "k", "M"} {
+			m := formPage(t)
+			m.conflict = &pendingConflict{}
+
+			next, cmd := m.Update(keyPress(k))
+
+			assert.Nil(t, next.(Model).conflict, k)
+			assert.True(t, next.(Model).applying, k)
+			assert.NotNil(t, cmd, k)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"fmt"
+	"palettesmith/internal/apply"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pendingConflict holds an apply that stopped because live files were
+// edited outside palettesmith, until the user picks how to proceed
+type pendingConflict struct {
+	targets   []apply.Target
+	conflicts []apply.Conflict
+}
+
+func (c pendingConflict) View() string {
+	var b strings.Builder
+	b.WriteString("These files were edited since palettesmith last wrote them:\n\n")
+	for _, cf := range c.conflicts {
+		fmt.Fprintf(&b, "• %s %s\n", cf.Path, helpStyle.Render("("+cf.PluginID+")"))
+	}
+	b.WriteString("\n")
+	b.WriteString("o  overwrite them with the new theme\n")
+	b.WriteString("k  keep their contents and skip them\n")
+	b.WriteString("m  keep their contents and put the theme in a managed block\n")
+	b.WriteString(helpStyle.Render("esc  cancel the apply"))
+	return b.String()
+}
+
+// resolveConflict handles keys while a conflict prompt is shown, re-running
+// the apply with the chosen policy
+func (m Model) resolveConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var policy apply.ConflictPolicy
+	switch msg.String() {
+	case "o", "O":
+		policy = apply.ConflictOverwrite
+	case "k", "K":
+		policy = apply.ConflictKeepTheirs
+	case "m", "M":
+		policy = apply.ConflictMerge
+	case "esc", "q", "ctrl+c":
+		m.conflict = nil
+		m.status = "Apply cancelled"
+		m.statusErr = false
+		return m, clearAfter(2 * time.Second)
+	default:
+		return m, nil
+	}
+	targets := m.conflict.targets
+	m.conflict = nil
+	m.applying = true
+	m.statusErr = false
+	m.status = "Applying…"
+	opts := m.applyOptions()
+	opts.OnConflict = policy
+	return m, applyCmd(m.cfg, targets, opts)
+}
@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"palettesmith/internal/apply"
+	"palettesmith/internal/diff"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+)
+
+// The diff palette is shared with the other pages for added/removed/heading text
+var (
+	diffAddStyle  = diff.AddStyle
+	diffDelStyle  = diff.DelStyle
+	diffHunkStyle = diff.HunkStyle
+	diffMetaStyle = diff.MetaStyle
+)
+
+// renderPreview formats the pending changes of every output file
+func renderPreview(diffs []apply.FileDiff) string {
+	if len(diffs) == 0 {
+		return helpStyle.Render("This target produces no files.")
+	}
+	var b strings.Builder
+	for _, d := range diffs {
+		if !d.Changed() {
+			b.WriteString(helpStyle.Render("unchanged: "+d.Path) + "\n\n")
+			continue
+		}
+		b.WriteString(diff.Colorize(d.Unified) + "\n")
+	}
+	return b.String()
+}
+
+// newDiffViewport builds a scrollable pane whose keys don't clash with the sidebar
+func newDiffViewport() viewport.Model {
+	vp := viewport.New(0, 0)
+	vp.KeyMap = viewport.KeyMap{
+		HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d")),
+		HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u")),
+		Down:         key.NewBinding(key.WithKeys("shift+down")),
+		Up:           key.NewBinding(key.WithKeys("shift+up")),
+	}
+	return vp
+}
@@ -5,7 +5,6 @@ import (
 	"palettesmith/internal/plugin"
 	"palettesmith/internal/theme"
 	"regexp"
-	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -26,6 +25,9 @@ type formModel struct {
 
 	pluginID string
 	theme    *theme.Store
+
+	// picker is the option list of the focused select field while open
+	picker *selectPicker
 }
 
 func newFormFromSpec(s plugin.Spec, pluginID string, th *theme.Store) formModel {
@@ -40,7 +42,7 @@ func newFormFromSpec(s plugin.Spec, pluginID string, th *theme.Store) formModel
 			ti.CharLimit = 10
 			ti.Width = 8
 		case "select":
-			// Render as text for now; later swap to a selector
+			// Never focused for typing; the value changes via cycleSelect and the picker
 			ti.CharLimit = 64
 			ti.Width = 24
 		default: // "text"
@@ -60,7 +62,7 @@ func newFormFromSpec(s plugin.Spec, pluginID string, th *theme.Store) formModel
 		if th != nil {
 			val = th.Resolve(pluginID, f.Key, f.Default)
 		}
-		ff := formField{spec: f, input: makeInput(f, val)}
+		ff := formField{spec: f, input: makeInput(f, val), err: validateValue(f, val)}
 		out = append(out, ff)
 		if n := len(f.Label); n > lw {
 			lw = n
@@ -74,11 +76,67 @@ func newFormFromSpec(s plugin.Spec, pluginID string, th *theme.Store) formModel
 		theme:    th,
 	}
 	if len(fm.fields) > 0 {
-		fm.fields[0].input.Focus()
+		fm.focus(0)
 	}
 	return fm
 }
 
+// focus moves the cursor to field i; select fields keep their input blurred
+// so keys cycle the value instead of editing it
+func (f *formModel) focus(i int) {
+	f.fields[f.focusIndex].input.Blur()
+	f.focusIndex = i
+	if f.fields[i].spec.Type != "select" {
+		f.fields[i].input.Focus()
+	}
+}
+
+// Picking reports whether a select field's option list is open and should
+// receive every key
+func (f formModel) Picking() bool { return f.picker != nil }
+
+// setValue stores a field's new value and records it as an override
+func (f *formModel) setValue(i int, v string) {
+	f.fields[i].input.SetValue(v)
+	f.fields[i].err = validateValue(f.fields[i].spec, v)
+	if f.theme != nil {
+		f.theme.SetOverride(f.pluginID, f.fields[i].spec.Key, v)
+	}
+}
+
+// cycleSelect moves a select field to the next (or previous) enum value,
+// wrapping around; a value outside the enum starts from either end
+func cycleSelect(spec plugin.Field, cur string, step int) string {
+	n := len(spec.Enum)
+	if n == 0 {
+		return cur
+	}
+	idx := -1
+	for i, o := range spec.Enum {
+		if o == cur {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		if step > 0 {
+			return spec.Enum[0]
+		}
+		return spec.Enum[n-1]
+	}
+	return spec.Enum[((idx+step)%n+n)%n]
+}
+
+// FocusedSelect reports whether the focused field is a select
+func (f formModel) FocusedSelect() bool {
+	return len(f.fields) > 0 && f.fields[f.focusIndex].spec.Type == "select"
+}
+
+// Typing reports whether the focused field is a text input, which takes
+// printable keys that are otherwise bound to actions
+func (f formModel) Typing() bool {
+	return len(f.fields) > 0 && !f.FocusedSelect()
+}
+
 func (f formModel) Palette() map[string]string {
 	m := make(map[string]string, len(f.fields))
 	for _, ff := range f.fields {
@@ -88,58 +146,82 @@ func (f formModel) Palette() map[string]string {
 }
 
 func (f formModel) Update(msg tea.Msg) (formModel, tea.Cmd) {
+	if f.picker != nil {
+		return f.updatePicker(msg)
+	}
+	if len(f.fields) == 0 {
+		return f, nil
+	}
+	cur := &f.fields[f.focusIndex]
+
 	switch m := msg.(type) {
 	case tea.KeyMsg:
 		switch m.String() {
 		case "up":
 			if f.focusIndex > 0 {
-				f.fields[f.focusIndex].input.Blur()
-				f.focusIndex--
-				f.fields[f.focusIndex].input.Focus()
+				f.focus(f.focusIndex - 1)
 			}
 			return f, nil
 		case "down":
 			if f.focusIndex < len(f.fields)-1 {
-				f.fields[f.focusIndex].input.Blur()
-				f.focusIndex++
-				f.fields[f.focusIndex].input.Focus()
+				f.focus(f.focusIndex + 1)
+			}
+			return f, nil
+		}
+		if cur.spec.Type == "select" {
+			switch m.String() {
+			case "left":
+				f.setValue(f.focusIndex, cycleSelect(cur.spec, cur.input.Value(), -1))
+			case "right":
+				f.setValue(f.focusIndex, cycleSelect(cur.spec, cur.input.Value(), 1))
+			case "enter":
+				if len(cur.spec.Enum) > 0 {
+					p := newSelectPicker(cur.spec.Enum, cur.input.Value())
+					f.picker = &p
+					return f, textinput.Blink
+				}
 			}
 			return f, nil
 		}
 	}
+	if cur.spec.Type == "select" {
+		return f, nil
+	}
 
+	before := cur.input.Value()
 	var cmd tea.Cmd
-	for i := range f.fields {
-		if i == f.focusIndex {
-			f.fields[i].input, cmd = f.fields[i].input.Update(msg)
-			f.fields[i].err = validateValue(f.fields[i].spec, f.fields[i].input.Value())
-			if f.theme != nil {
-				f.theme.SetOverride(f.pluginID, f.fields[i].spec.Key, f.fields[i].input.Value())
+	cur.input, cmd = cur.input.Update(msg)
+	if v := cur.input.Value(); v != before {
+		f.setValue(f.focusIndex, v)
+	}
+	return f, cmd
+}
+
+// updatePicker drives the open option list: enter picks, esc closes
+func (f formModel) updatePicker(msg tea.Msg) (formModel, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		switch k.String() {
+		case "esc":
+			f.picker = nil
+			return f, nil
+		case "enter":
+			if v, ok := f.picker.Selected(); ok {
+				f.setValue(f.focusIndex, v)
 			}
+			f.picker = nil
+			return f, nil
 		}
 	}
+	p, cmd := f.picker.Update(msg)
+	f.picker = &p
 	return f, cmd
 }
 
 var colorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
 
 func validateValue(spec plugin.Field, v string) string {
-	switch spec.Type {
-	case "color":
-		if !colorRe.MatchString(v) {
-			return "expect #RRGGBB"
-		}
-	case "number":
-		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
-		if err != nil {
-			return "not a number"
-		}
-		if spec.Min != nil && n < *spec.Min {
-			return fmt.Sprintf("< %.0f", *spec.Min)
-		}
-		if spec.Max != nil && n > *spec.Max {
-			return fmt.Sprintf("> %.0f", *spec.Max)
-		}
+	if err := spec.Validate(v); err != nil {
+		return err.Error()
 	}
 	return ""
 }
@@ -189,10 +271,18 @@ func (f formModel) View() string {
 
 		tag := tagStyle.Render(" [" + source + "]")
 
-		row := fmt.Sprintf("%s%s  %s%s%s%s%s", cursor, label, fld.input.View(), swatch, err, help, tag)
+		value := fld.input.View()
+		if fld.spec.Type == "select" {
+			value = "‹ " + fld.input.Value() + " ›"
+		}
+
+		row := fmt.Sprintf("%s%s  %s%s%s%s%s", cursor, label, value, swatch, err, help, tag)
 
 		if i == f.focusIndex {
 			fmt.Fprintln(&b, rowFocus.Render(row))
+			if f.picker != nil {
+				b.WriteString(f.picker.View(2 + f.labelW + 2))
+			}
 		} else {
 			fmt.Fprintln(&b, rowNormal.Render(row))
 		}
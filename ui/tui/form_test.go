@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"testing"
+
+	"palettesmith/internal/plugin"
+	"palettesmith/internal/theme"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func layoutForm(t *testing.T) (formModel, *theme.Store) {
+	t.Helper()
+	th := theme.NewStore(theme.ThemeConfig{})
+	spec := plugin.Spec{Fields: []plugin.Field{
+		{Key: "layout", Label: "Layout", Type: "select", Default: "dwindle", Enum: []string{"dwindle", "master", "scrolling"}},
+	}}
+	return newFormFromSpec(spec, "hyprland", th), th
+}
+
+func keyPress(s string) tea.KeyMsg {
+	switch s {
+	case "left":
+		return tea.KeyMsg{Type: tea.KeyLeft}
+	case "right":
+		return tea.KeyMsg{Type: tea.KeyRight}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestForm_Select(t *testing.T) {
+	t.Run("should_cycle_through_the_enum_with_left_and_right", func(t *testing.T) {
+		f, th := layoutForm(t)
+
+		f, _ = f.Update(keyPress("left"))
+		assert.Equal(t, "scrolling", f.Palette()["layout"])
+		f, _ = f.Update(keyPress("right"))
+		f, _ = f.Update(keyPress("right"))
+		assert.Equal(t, "master", f.Palette()["layout"])
+		assert.True(t, th.HasOverride("hyprland", "layout"))
+	})
+
+	t.Run("should_ignore_typed_text", func(t *testing.T) {
+		f, _ := layoutForm(t)
+
+		f, _ = f.Update(keyPress("x"))
+
+		assert.Equal(t, "dwindle", f.Palette()["layout"])
+	})
+
+	t.Run("should_pick_from_the_filtered_list", func(t *testing.T) {
+		f, _ := layoutForm(t)
+
+		f, _ = f.Update(keyPress("enter"))
+		require.True(t, f.Picking())
+		f, _ = f.Update(keyPress("s"))
+		f, _ = f.Update(keyPress("c"))
+		f, _ = f.Update(keyPress("enter"))
+
+		assert.False(t, f.Picking())
+		assert.Equal(t, "scrolling", f.Palette()["layout"])
+	})
+
+	t.Run("should_keep_the_value_when_the_list_is_dismissed", func(t *testing.T) {
+		f, _ := layoutForm(t)
+
+		f, _ = f.Update(keyPress("enter"))
+		f, _ = f.Update(keyPress("down"))
+		f, _ = f.Update(keyPress("esc"))
+
+		assert.False(t, f.Picking())
+		assert.Equal(t, "dwindle", f.Palette()["layout"])
+	})
+
+	t.Run("should_flag_a_value_outside_the_enum", func(t *testing.T) {
+		th := theme.NewStore(theme.ThemeConfig{ThemeDefaults: map[string]string{"layout": "spiral"}})
+		spec := plugin.Spec{Fields: []plugin.Field{
+			{Key: "layout", Type: "select", Default: "dwindle", Enum: []string{"dwindle", "master"}},
+		}}
+
+		f := newFormFromSpec(spec, "hyprland", th)
+
+		assert.Equal(t, "not one of dwindle, master", f.fields[0].err)
+	})
+}
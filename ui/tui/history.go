@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"fmt"
+	"palettesmith/internal/config"
+	"palettesmith/internal/history"
+	"strings"
+)
+
+// refreshHistory reloads the apply journal into the history pane
+func (m Model) refreshHistory() Model {
+	path, err := config.HistoryPath()
+	var entries []history.Entry
+	if err == nil {
+		entries, err = history.Read(path)
+	}
+	if err != nil {
+		m.historyView.SetContent(diffDelStyle.Render("Cannot read history: " + err.Error()))
+		return m
+	}
+	m.historyView.SetContent(renderHistory(entries))
+	m.historyView.GotoTop()
+	return m
+}
+
+// renderHistory lists journal entries, newest first
+func renderHistory(entries []history.Entry) string {
+	if len(entries) == 0 {
+		return helpStyle.Render("No applies recorded yet.")
+	}
+	var b strings.Builder
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		b.WriteString(diffMetaStyle.Render(e.Time.Local().Format("2006-01-02 15:04:05")))
+		fmt.Fprintf(&b, "  %s %s\n", nz(e.Theme, "—"), helpStyle.Render("→ "+strings.Join(e.Targets, ", ")))
+		for _, f := range e.Files {
+			fmt.Fprintf(&b, "  %s %s\n", f.Path, helpStyle.Render(fmt.Sprintf("%.12s", f.SHA256)))
+		}
+		for _, r := range e.Reloads {
+			if r.Failed() {
+				b.WriteString("  " + diffDelStyle.Render(fmt.Sprintf("reload %s failed: %s", r.Target, r.Error)) + "\n")
+				continue
+			}
+			b.WriteString("  " + diffAddStyle.Render(fmt.Sprintf("reload %s ok", r.Target)) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pickerRows caps how many matching options are listed at once
+const pickerRows = 8
+
+// selectPicker is the filtered option list a select field opens on enter
+type selectPicker struct {
+	options []string
+	filter  textinput.Model
+	cursor  int
+}
+
+func newSelectPicker(options []string, current string) selectPicker {
+	ti := textinput.New()
+	ti.Prompt = "/ "
+	ti.Placeholder = "filter"
+	ti.Width = 24
+	ti.Focus()
+	p := selectPicker{options: options, filter: ti}
+	for i, o := range options {
+		if o == current {
+			p.cursor = i
+		}
+	}
+	return p
+}
+
+// matches lists the options containing the filter text, ignoring case
+func (p selectPicker) matches() []string {
+	q := strings.ToLower(strings.TrimSpace(p.filter.Value()))
+	if q == "" {
+		return p.options
+	}
+	var out []string
+	for _, o := range p.options {
+		if strings.Contains(strings.ToLower(o), q) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Selected returns the highlighted option, if any option matches
+func (p selectPicker) Selected() (string, bool) {
+	ms := p.matches()
+	if len(ms) == 0 {
+		return "", false
+	}
+	return ms[min(p.cursor, len(ms)-1)], true
+}
+
+func (p selectPicker) Update(msg tea.Msg) (selectPicker, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		switch k.String() {
+		case "up":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+			return p, nil
+		case "down":
+			if p.cursor < len(p.matches())-1 {
+				p.cursor++
+			}
+			return p, nil
+		}
+	}
+	before := p.filter.Value()
+	var cmd tea.Cmd
+	p.filter, cmd = p.filter.Update(msg)
+	if p.filter.Value() != before {
+		p.cursor = 0
+	}
+	return p, cmd
+}
+
+func (p selectPicker) View(indent int) string {
+	pad := strings.Repeat(" ", indent)
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#777777"))
+	hi := lipgloss.NewStyle().Foreground(lipgloss.Color("#e6e6e6")).Bold(true)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s\n", pad, p.filter.View())
+	ms := p.matches()
+	if len(ms) == 0 {
+		fmt.Fprintf(&b, "%s%s\n", pad, dim.Render("no matches"))
+		return b.String()
+	}
+	cur := min(p.cursor, len(ms)-1)
+	// Keep the cursor inside the visible window
+	start := max(0, cur-pickerRows+1)
+	end := min(len(ms), start+pickerRows)
+	for i := start; i < end; i++ {
+		if i == cur {
+			fmt.Fprintf(&b, "%s%s\n", pad, hi.Render("▸ "+ms[i]))
+		} else {
+			fmt.Fprintf(&b, "%s%s\n", pad, dim.Render("  "+ms[i]))
+		}
+	}
+	if end < len(ms) {
+		fmt.Fprintf(&b, "%s%s\n", pad, dim.Render(fmt.Sprintf("  … %d more", len(ms)-end)))
+	}
+	return b.String()
+}
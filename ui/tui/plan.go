@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"fmt"
+	"palettesmith/internal/apply"
+	"palettesmith/internal/plugin"
+	"strings"
+)
+
+// refreshPlan recomputes the dry-run plan when the targets it covers change
+func (m Model) refreshPlan() Model {
+	ids := m.targetIDs()
+	key := strings.Join(ids, ",")
+	if m.planFor == key && key != "" {
+		return m
+	}
+	m.planFor = key
+	if len(ids) == 0 || m.store == nil {
+		m.planView.SetContent(helpStyle.Render("Select or mark targets to plan an apply."))
+		return m
+	}
+
+	plugs := make([]plugin.Plugin, 0, len(ids))
+	for _, id := range ids {
+		if plug, ok := m.store.Get(id); ok {
+			plugs = append(plugs, plug)
+		}
+	}
+	plan, err := apply.BuildPlan(m.theme.Theme, plugs, m.theme)
+	if err != nil {
+		m.planView.SetContent(diffDelStyle.Render("Cannot plan: " + err.Error()))
+		return m
+	}
+	m.planView.SetContent(renderPlan(plan))
+	m.planView.GotoTop()
+	return m
+}
+
+// renderPlan formats a plan target by target
+func renderPlan(plan apply.Plan) string {
+	var b strings.Builder
+	for _, t := range plan.Targets {
+		b.WriteString(diffMetaStyle.Render(firstNonEmpty(t.Title, t.ID)) + helpStyle.Render(" · "+t.Mode) + "\n")
+		if t.Error != "" {
+			b.WriteString("  " + diffDelStyle.Render(t.Error) + "\n")
+		}
+		for _, v := range t.Values {
+			fmt.Fprintf(&b, "  %s = %s %s\n", v.Key, v.Value, helpStyle.Render("("+v.Source+")"))
+		}
+		for _, f := range t.Files {
+			switch f.Action {
+			case apply.ActionCreate:
+				b.WriteString("  " + diffAddStyle.Render("create "+f.Path) + "\n")
+			case apply.ActionModify:
+				b.WriteString("  " + diffHunkStyle.Render("modify "+f.Path) + "\n")
+			default:
+				b.WriteString("  " + helpStyle.Render("unchanged "+f.Path) + "\n")
+			}
+		}
+		if len(t.Verify) > 0 {
+			b.WriteString("  verify: " + strings.Join(t.Verify, " ") + "\n")
+		}
+		if len(t.Reload) > 0 {
+			b.WriteString("  reload: " + strings.Join(t.Reload, " ") + "\n")
+		}
+		for _, w := range t.Warnings {
+			b.WriteString("  " + helpStyle.Render("warning: "+w) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -1,17 +1,37 @@
 package tui
 
 import (
+	"io"
+
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 type targetItem struct {
 	id          string
 	title       string
 	description string
+	marked      bool
+
+	// Plugins that failed to load or were shadowed are listed without an id
+	// so they can't be applied; problem says why
+	problem  string
+	shadowed bool
+	dir      string
 }
 
-func (i targetItem) Title() string       { return i.title }
+func (i targetItem) Title() string {
+	switch {
+	case i.problem != "" && i.shadowed:
+		return "! " + i.title
+	case i.problem != "":
+		return "✗ " + i.title
+	case i.marked:
+		return "● " + i.title
+	}
+	return i.title
+}
 func (i targetItem) Description() string { return i.description }
 func (i targetItem) FilterValue() string { return i.title }
 
@@ -19,8 +39,31 @@ type Sidebar struct {
 	l list.Model
 }
 
+var (
+	problemErrColor  = lipgloss.Color("#ff6b6b")
+	problemWarnColor = lipgloss.Color("#e0af68")
+)
+
+// targetDelegate renders broken and shadowed plugins in a warning color
+type targetDelegate struct {
+	list.DefaultDelegate
+}
+
+func (d targetDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if it, ok := item.(targetItem); ok && it.problem != "" {
+		color := problemErrColor
+		if it.shadowed {
+			color = problemWarnColor
+		}
+		d.Styles.NormalTitle = d.Styles.NormalTitle.Foreground(color)
+		d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(color).BorderForeground(color)
+		d.Styles.SelectedDesc = d.Styles.SelectedDesc.BorderForeground(color)
+	}
+	d.DefaultDelegate.Render(w, m, index, item)
+}
+
 func NewSidebar(items []list.Item) Sidebar {
-	delegate := list.NewDefaultDelegate()
+	delegate := targetDelegate{list.NewDefaultDelegate()}
 	l := list.New(items, delegate, 28, 16)
 	l.Title = "Targets"
 	l.SetShowHelp(false)
@@ -40,6 +83,11 @@ func (s Sidebar) Update(msg tea.Msg) (Sidebar, tea.Cmd) {
 	return s, cmd
 }
 
+// Filtering reports whether the user is typing a filter query
+func (s Sidebar) Filtering() bool {
+	return s.l.FilterState() == list.Filtering
+}
+
 func (s Sidebar) View() string {
 	return s.l.View()
 }
@@ -51,9 +99,63 @@ func (s Sidebar) SelectedID() string {
 	return ""
 }
 
+// SelectedProblem returns the selected entry when it is a broken or shadowed plugin
+func (s Sidebar) SelectedProblem() (targetItem, bool) {
+	it, ok := s.l.SelectedItem().(targetItem)
+	return it, ok && it.problem != ""
+}
+
 func (s Sidebar) SelectedTitle() string {
 	if it, ok := s.l.SelectedItem().(targetItem); ok {
 		return it.title
 	}
 	return ""
 }
+
+// ToggleMark marks or unmarks the selected target for a multi-target apply
+func (s *Sidebar) ToggleMark() {
+	it, ok := s.l.SelectedItem().(targetItem)
+	if !ok || it.id == "" {
+		return
+	}
+	it.marked = !it.marked
+	s.l.SetItem(s.l.GlobalIndex(), it)
+}
+
+// ToggleMarkAll marks every target, or clears all marks if all are marked
+func (s *Sidebar) ToggleMarkAll() {
+	all := true
+	for _, li := range s.l.Items() {
+		if it, ok := li.(targetItem); ok && it.id != "" && !it.marked {
+			all = false
+			break
+		}
+	}
+	for i, li := range s.l.Items() {
+		if it, ok := li.(targetItem); ok && it.id != "" {
+			it.marked = !all
+			s.l.SetItem(i, it)
+		}
+	}
+}
+
+// ClearMarks unmarks every target
+func (s *Sidebar) ClearMarks() {
+	for i, li := range s.l.Items() {
+		if it, ok := li.(targetItem); ok && it.marked {
+			it.marked = false
+			s.l.SetItem(i, it)
+		}
+	}
+}
+
+// MarkedIDs returns the ids of the marked targets in sidebar order
+func (s Sidebar) MarkedIDs() []string {
+	var ids []string
+	for _, li := range s.l.Items() {
+		if it, ok := li.(targetItem); ok && it.marked {
+			ids = append(ids, it.id)
+		}
+	}
+	return ids
+}